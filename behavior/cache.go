@@ -0,0 +1,321 @@
+package behavior
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// Cacheable is implemented by requests whose response can be cached by the [Cache] behavior.
+	Cacheable interface {
+		// CacheKey identifies the response, requests with the same key share it.
+		// Keys only have to be unique per request type.
+		CacheKey() string
+		// TTL is how long the response is cached, the response isn't cached if it isn't positive.
+		TTL() time.Duration
+	}
+
+	// CacheTagged is implemented by [Cacheable] requests that tag their response,
+	// so it can be invalidated with the notifications of [WithInvalidation].
+	CacheTagged interface {
+		CacheTags() []string
+	}
+
+	// CacheEntry is a cached response.
+	CacheEntry struct {
+		Value     any
+		Tags      []string
+		ExpiresAt time.Time
+	}
+
+	// CacheStore stores the responses of the [Cache] behavior.
+	// It has to be safe for concurrent use.
+	CacheStore interface {
+		// Get returns the entry of the key, the ok result is false if there is no entry.
+		Get(ctx context.Context, key string) (entry CacheEntry, ok bool, err error)
+		Set(ctx context.Context, key string, entry CacheEntry) error
+		Delete(ctx context.Context, key string) error
+		// InvalidateTags deletes every entry that has one of the tags.
+		InvalidateTags(ctx context.Context, tags ...string) error
+	}
+
+	// Cache is a [mediator.Behavior] that caches the responses of [Cacheable] requests.
+	//
+	// Concurrent requests with the same key that miss the cache are handled once and share the response.
+	// Errors and the results of stream requests aren't cached.
+	//
+	// Cached responses are invalidated by tag when a notification type of [WithInvalidation] is published.
+	// The behavior subscribes to the [mediator.Mediator] that handles the first [Cacheable] request.
+	Cache struct {
+		opts    cacheOptions
+		flights flightGroup
+		// invalidations counts the invalidations, responses that were handled during one aren't stored
+		invalidations atomic.Uint64
+		// invalidating is held for writing while tags are invalidated, and for reading while responses are stored,
+		// so a response can't be stored after the invalidation that made it stale
+		invalidating sync.RWMutex
+
+		mu         sync.Mutex
+		subscribed map[mediator.Publisher]struct{}
+	}
+
+	// CacheOption defines the method to customize [NewCache].
+	CacheOption  func(*cacheOptions)
+	cacheOptions struct {
+		store         CacheStore
+		invalidations []invalidation
+		clock         Clock
+	}
+
+	// invalidation subscribes to a notification type that invalidates tags.
+	invalidation func(p mediator.Publisher, invalidate func(ctx context.Context, l *slog.Logger, tags []string)) error
+
+	// LRUStore is a [CacheStore] that keeps a limited amount of entries in memory,
+	// it evicts the least recently used entry when it is full.
+	LRUStore struct {
+		capacity int
+		mu       sync.Mutex
+		order    *list.List
+		entries  map[string]*list.Element
+		tags     map[string]map[string]struct{}
+	}
+
+	lruEntry struct {
+		key   string
+		entry CacheEntry
+	}
+)
+
+// WithCacheStore sets the [CacheStore] that holds the responses.
+// The default is an [LRUStore] with a capacity of 1000 entries.
+func WithCacheStore(store CacheStore) CacheOption {
+	return func(o *cacheOptions) {
+		o.store = store
+	}
+}
+
+// WithInvalidation invalidates the tags returned by the function when a notification of type N is published.
+func WithInvalidation[N any](tags func(notification N) []string) CacheOption {
+	return func(o *cacheOptions) {
+		o.invalidations = append(o.invalidations, func(p mediator.Publisher, invalidate func(ctx context.Context, l *slog.Logger, tags []string)) error {
+			_, err := mediator.Subscribe(p, mediator.NewNotificationHandler(func(ctx context.Context, l *slog.Logger, notification N) error {
+				invalidate(ctx, l, tags(notification))
+				return nil
+			}))
+			return err
+		})
+	}
+}
+
+// WithCacheClock replaces the [Clock] that is used to expire responses, this is useful in tests.
+func WithCacheClock(clock Clock) CacheOption {
+	return func(o *cacheOptions) {
+		o.clock = clock
+	}
+}
+
+// Handler runs the [Cache] behavior.
+func (b *Cache) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		c, ok := msg.GetInner().(Cacheable)
+		// the results of a stream don't pass through the behavior, so they can't be cached
+		if !ok || c.TTL() <= 0 || mediator.IsStream(msg) {
+			return next.Handle(ctx, l, msg)
+		}
+		b.subscribe(ctx, l)
+
+		key := typeScopedKey(msg, c.CacheKey())
+		if entry, ok := b.get(ctx, l, key); ok {
+			return entry.Value, nil
+		}
+
+		resp, _, err := b.flights.do(ctx, key, func(ctx context.Context) (any, error) {
+			invalidations := b.invalidations.Load()
+			resp, err := next.Handle(ctx, l, msg)
+			if err != nil {
+				return resp, err
+			}
+			b.set(ctx, l, msg, c, key, resp, invalidations)
+			return resp, nil
+		})
+		return resp, err
+	})
+}
+
+// set stores the response, unless tags were invalidated since the invalidation count was read.
+func (b *Cache) set(ctx context.Context, l *slog.Logger, msg mediator.Message, c Cacheable, key string, resp any, invalidations uint64) {
+	b.invalidating.RLock()
+	defer b.invalidating.RUnlock()
+	if b.invalidations.Load() != invalidations {
+		return
+	}
+
+	entry := CacheEntry{Value: resp, ExpiresAt: b.opts.clock.Now().Add(c.TTL())}
+	if tagged, ok := c.(CacheTagged); ok {
+		entry.Tags = tagged.CacheTags()
+	}
+	if err := b.opts.store.Set(ctx, key, entry); err != nil {
+		l.WarnContext(ctx, "failed to cache the response of "+msg.String(), slog.Any("error", err))
+	}
+}
+
+// get returns the entry of the key if it is cached and didn't expire.
+func (b *Cache) get(ctx context.Context, l *slog.Logger, key string) (CacheEntry, bool) {
+	entry, ok, err := b.opts.store.Get(ctx, key)
+	if err != nil {
+		l.WarnContext(ctx, "failed to get "+key+" from the cache", slog.Any("error", err))
+		return CacheEntry{}, false
+	}
+	if !ok {
+		return CacheEntry{}, false
+	}
+	if !b.opts.clock.Now().Before(entry.ExpiresAt) {
+		if err := b.opts.store.Delete(ctx, key); err != nil {
+			l.WarnContext(ctx, "failed to delete "+key+" from the cache", slog.Any("error", err))
+		}
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// subscribe subscribes the invalidations to the mediator in the context, once.
+func (b *Cache) subscribe(ctx context.Context, l *slog.Logger) {
+	if len(b.opts.invalidations) == 0 {
+		return
+	}
+	p, ok := mediator.SenderFromContext(ctx).(mediator.Publisher)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribed[p]; ok {
+		return
+	}
+	b.subscribed[p] = struct{}{}
+	for _, subscribe := range b.opts.invalidations {
+		if err := subscribe(p, b.invalidate); err != nil {
+			l.ErrorContext(ctx, "failed to subscribe the cache invalidation", slog.Any("error", err))
+		}
+	}
+}
+
+func (b *Cache) invalidate(ctx context.Context, l *slog.Logger, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	b.invalidating.Lock()
+	defer b.invalidating.Unlock()
+	b.invalidations.Add(1)
+	if err := b.opts.store.InvalidateTags(ctx, tags...); err != nil {
+		l.ErrorContext(ctx, "failed to invalidate the cache", slog.Any("tags", tags), slog.Any("error", err))
+	}
+}
+
+// Get implements [CacheStore].
+func (s *LRUStore) Get(_ context.Context, key string) (CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*lruEntry).entry, true, nil
+}
+
+// Set implements [CacheStore].
+func (s *LRUStore) Set(_ context.Context, key string, entry CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.remove(e)
+	}
+	s.entries[key] = s.order.PushFront(&lruEntry{key: key, entry: entry})
+	for _, tag := range entry.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Delete implements [CacheStore].
+func (s *LRUStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.remove(e)
+	}
+	return nil
+}
+
+// InvalidateTags implements [CacheStore].
+func (s *LRUStore) InvalidateTags(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(s.entries[key])
+		}
+	}
+	return nil
+}
+
+// Len returns the amount of entries in the store.
+func (s *LRUStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// remove removes the element and its tags, the lock has to be held.
+func (s *LRUStore) remove(e *list.Element) {
+	le := s.order.Remove(e).(*lruEntry)
+	delete(s.entries, le.key)
+	for _, tag := range le.entry.Tags {
+		delete(s.tags[tag], le.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// NewLRUStore creates a new [LRUStore] that holds up to capacity entries.
+func NewLRUStore(capacity int) *LRUStore {
+	return &LRUStore{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+// NewCache creates a new [Cache] [mediator.Behavior].
+func NewCache(opt ...CacheOption) mediator.Behavior {
+	// default options
+	opts := cacheOptions{
+		clock: realClock{},
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if opts.store == nil {
+		opts.store = NewLRUStore(1000)
+	}
+	return &Cache{
+		opts:       opts,
+		subscribed: make(map[mediator.Publisher]struct{}),
+	}
+}
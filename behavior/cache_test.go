@@ -0,0 +1,301 @@
+package behavior_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+type (
+	// searchGopher is a cacheable query that counts how often it was handled.
+	searchGopher struct {
+		name    string
+		calls   *atomic.Int32
+		err     error
+		release <-chan struct{}
+	}
+
+	gopherRenamed struct {
+		name string
+	}
+)
+
+func (q searchGopher) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	q.calls.Add(1)
+	if q.release != nil {
+		<-q.release
+	}
+	if q.err != nil {
+		return "", q.err
+	}
+	return "gopher " + q.name, nil
+}
+
+func (q searchGopher) CacheKey() string {
+	return q.name
+}
+
+func (q searchGopher) TTL() time.Duration {
+	return time.Minute
+}
+
+func (q searchGopher) CacheTags() []string {
+	return []string{"gopher:" + q.name}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache(behavior.WithCacheClock(clock))))
+
+	calls := &atomic.Int32{}
+	for range 2 {
+		resp, err := mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls})
+		require.NoError(t, err)
+		assert.Equal(t, "gopher Gus", resp)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "the second query should be served from the cache")
+
+	_, err := mediator.Send(ctx, m, searchGopher{name: "Gert", calls: calls})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "queries with another key shouldn't share the response")
+
+	clock.Advance(time.Minute)
+	_, err = mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "the response should expire after the TTL")
+}
+
+func TestCache_Errors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache()))
+
+	calls := &atomic.Int32{}
+	reqErr := errors.New("database is down")
+	for range 2 {
+		_, err := mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls, err: reqErr})
+		require.ErrorIs(t, err, reqErr)
+	}
+	assert.Equal(t, int32(2), calls.Load(), "errors shouldn't be cached")
+}
+
+// waitForWaiters waits until the given amount of callers wait for a shared response.
+func waitForWaiters(t *testing.T, b interface{ Waiters() int }, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return b.Waiters() == n }, time.Second, time.Millisecond)
+}
+
+func TestCache_ConcurrentMisses(t *testing.T) {
+	t.Parallel()
+
+	b := behavior.NewCache().(*behavior.Cache)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := mediator.Send(context.Background(), m, searchGopher{name: "Gus", calls: calls, release: release})
+			assert.NoError(t, err)
+			assert.Equal(t, "gopher Gus", resp)
+		}()
+	}
+	waitForWaiters(t, b, 5)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "concurrent misses should be handled once")
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache(
+		behavior.WithInvalidation(func(n gopherRenamed) []string {
+			return []string{"gopher:" + n.name}
+		}),
+	)))
+
+	calls := &atomic.Int32{}
+	_, err := mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls})
+	require.NoError(t, err)
+	_, err = mediator.Send(ctx, m, searchGopher{name: "Gert", calls: calls})
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, m, gopherRenamed{name: "Gus"}))
+
+	_, err = mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls})
+	require.NoError(t, err)
+	_, err = mediator.Send(ctx, m, searchGopher{name: "Gert", calls: calls})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "only the tagged response should be invalidated")
+}
+
+func TestLRUStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := behavior.NewLRUStore(2)
+
+	require.NoError(t, store.Set(ctx, "a", behavior.CacheEntry{Value: 1, Tags: []string{"odd"}}))
+	require.NoError(t, store.Set(ctx, "b", behavior.CacheEntry{Value: 2, Tags: []string{"even"}}))
+	_, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// b is the least recently used entry
+	require.NoError(t, store.Set(ctx, "c", behavior.CacheEntry{Value: 3, Tags: []string{"odd"}}))
+	assert.Equal(t, 2, store.Len())
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok, "the least recently used entry should be evicted")
+
+	require.NoError(t, store.InvalidateTags(ctx, "odd"))
+	assert.Equal(t, 0, store.Len())
+
+	require.NoError(t, store.Set(ctx, "a", behavior.CacheEntry{Value: 1}))
+	require.NoError(t, store.Delete(ctx, "a"))
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+type (
+	getUser struct {
+		id string
+	}
+	getOrder struct {
+		id string
+	}
+)
+
+func (q *getUser) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	return "user-" + q.id, nil
+}
+
+func (q *getUser) CacheKey() string {
+	return q.id
+}
+
+func (q *getUser) TTL() time.Duration {
+	return time.Minute
+}
+
+func (q *getOrder) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	return "order-" + q.id, nil
+}
+
+func (q *getOrder) CacheKey() string {
+	return q.id
+}
+
+func (q *getOrder) TTL() time.Duration {
+	return time.Minute
+}
+
+func TestCache_PointerRequests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache()))
+
+	user, err := mediator.Send(ctx, m, &getUser{id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", user)
+
+	order, err := mediator.Send(ctx, m, &getOrder{id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", order, "requests of different types shouldn't share a key")
+}
+
+func TestCache_InvalidatedWhileHandled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache(
+		behavior.WithInvalidation(func(n gopherRenamed) []string {
+			return []string{"gopher:" + n.name}
+		}),
+	)))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls, release: release})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, mediator.Publish(ctx, m, gopherRenamed{name: "Gus"}))
+	close(release)
+	require.NoError(t, <-done)
+
+	_, err := mediator.Send(ctx, m, searchGopher{name: "Gus", calls: calls})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "a response that was invalidated while it was handled shouldn't be cached")
+}
+
+// gopherStream streams the names of the gophers.
+type gopherStream struct {
+	calls *atomic.Int32
+}
+
+func (q gopherStream) Handle(_ context.Context, _ *slog.Logger) iter.Seq2[string, error] {
+	q.calls.Add(1)
+	return func(yield func(string, error) bool) {
+		for _, name := range []string{"Gus", "Gert", "Gopher"} {
+			if !yield(name, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (q gopherStream) CacheKey() string {
+	return "all"
+}
+
+func (q gopherStream) TTL() time.Duration {
+	return time.Minute
+}
+
+// collectStream sends the stream and returns its results.
+func collectStream(t *testing.T, m mediator.Sender, q gopherStream) []string {
+	t.Helper()
+	var names []string
+	for name, err := range mediator.SendStream(context.Background(), m, q) {
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestCache_Stream(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCache()))
+
+	calls := &atomic.Int32{}
+	for range 2 {
+		assert.Equal(t, []string{"Gus", "Gert", "Gopher"}, collectStream(t, m, gopherStream{calls: calls}))
+	}
+	assert.Equal(t, int32(2), calls.Load(), "streams shouldn't be cached")
+}
@@ -0,0 +1,394 @@
+package behavior
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+// CircuitState is the state of a circuit of the [CircuitBreaker] behavior.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every message through, it is the initial state.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every message with [ErrCircuitOpen].
+	CircuitOpen
+	// CircuitHalfOpen lets a limited amount of probe messages through, to test if the circuit can be closed again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// minCircuitSweepSize is the number of circuits the [CircuitBreaker] holds before it removes the unused circuits.
+const minCircuitSweepSize = 64
+
+// ErrCircuitOpen is returned by the [CircuitBreaker] behavior when a message is rejected, because its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type (
+	// CircuitStateChanged is the notification that the [CircuitBreaker] behavior publishes when a circuit changes state.
+	CircuitStateChanged struct {
+		// Key identifies the circuit, it is the full type of the message by default.
+		Key  string
+		From CircuitState
+		To   CircuitState
+		At   time.Time
+	}
+
+	// CircuitBreaker is a [mediator.Behavior] that stops handling messages when too many of them fail.
+	//
+	// Every message type, or key returned by the function of [WithCircuitKey], has its own circuit.
+	// A circuit opens when the ratio of failures reaches the failure ratio,
+	// after at least the minimum volume of messages were handled in the current window.
+	// An open circuit rejects messages with [ErrCircuitOpen] until the open timeout passed.
+	// It then lets the probe messages through while half-open,
+	// the circuit closes when all of them succeed and opens again when one fails or panics.
+	//
+	// State changes are reported to the callbacks of [WithStateChange] and published as a [CircuitStateChanged] notification.
+	// Closed circuits that didn't handle messages for a window are removed
+	// whenever the number of circuits doubled since they were last removed.
+	CircuitBreaker struct {
+		opts     circuitBreakerOptions
+		mu       sync.Mutex
+		circuits map[string]*circuit
+		// sweepSize is the number of circuits at which the unused circuits are removed
+		sweepSize int
+	}
+
+	// CircuitBreakerOption defines the method to customize [NewCircuitBreaker].
+	CircuitBreakerOption  func(*circuitBreakerOptions)
+	circuitBreakerOptions struct {
+		key          func(msg mediator.Message) string
+		failureRatio float64
+		minVolume    int
+		window       time.Duration
+		openTimeout  time.Duration
+		probes       int
+		isFailure    func(err error) bool
+		onChange     []func(ctx context.Context, change CircuitStateChanged)
+		publisher    mediator.Publisher
+		clock        Clock
+	}
+
+	// circuitResult is the result of a message that was let through by a circuit.
+	circuitResult int
+
+	circuit struct {
+		state       CircuitState
+		since       time.Time
+		windowStart time.Time
+		total       int
+		failures    int
+		// probes is the amount of probes that were let through while half-open
+		probes    int
+		successes int
+		// active is the amount of messages that are being handled
+		active int
+		// generation changes with the state, results of messages from another generation are ignored
+		generation uint64
+	}
+)
+
+const (
+	resultSuccess circuitResult = iota
+	resultFailure
+	// resultIgnored is the result of a message that failed with an error that doesn't count as a failure
+	resultIgnored
+)
+
+// WithCircuitKey sets the function that decides which circuit a message belongs to.
+// By default, every message type has its own circuit.
+func WithCircuitKey(key func(msg mediator.Message) string) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.key = key
+	}
+}
+
+// WithFailureRatio sets the ratio of failed messages, between 0 and 1, that opens the circuit.
+// The default is 0.5.
+func WithFailureRatio(ratio float64) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.failureRatio = ratio
+	}
+}
+
+// WithMinimumVolume sets the amount of messages that have to be handled in a window, before the circuit can open.
+// The default is 10.
+func WithMinimumVolume(volume int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.minVolume = volume
+	}
+}
+
+// WithCircuitWindow sets the duration after which the counts of a closed circuit are reset.
+// The default is 1 minute.
+func WithCircuitWindow(window time.Duration) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.window = window
+	}
+}
+
+// WithOpenTimeout sets how long a circuit stays open before it becomes half-open.
+// The default is 30 seconds.
+func WithOpenTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenProbes sets the amount of messages that are let through while the circuit is half-open.
+// The default is 1.
+func WithHalfOpenProbes(probes int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.probes = probes
+	}
+}
+
+// WithFailure sets the function that decides if an error counts as a failure.
+// Errors that don't count as a failure aren't counted at all, they don't close a half-open circuit.
+// By default, every error counts, except for the errors of a cancelled context.
+func WithFailure(isFailure func(err error) bool) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.isFailure = isFailure
+	}
+}
+
+// WithStateChange adds a callback that is called when a circuit changes state.
+func WithStateChange(onChange func(ctx context.Context, change CircuitStateChanged)) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.onChange = append(o.onChange, onChange)
+	}
+}
+
+// WithStatePublisher sets the [mediator.Publisher] that the [CircuitStateChanged] notifications are published on.
+// By default, they are published on the [mediator.Mediator] that handles the message, if there is one.
+// The notifications are published right away, they aren't queued by a [UnitOfWork] or stored in a [mediator.Outbox].
+func WithStatePublisher(p mediator.Publisher) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.publisher = p
+	}
+}
+
+// WithCircuitClock replaces the [Clock] that is used to track the timeouts, this is useful in tests.
+func WithCircuitClock(clock Clock) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.clock = clock
+	}
+}
+
+// Handler runs the [CircuitBreaker] behavior.
+func (b *CircuitBreaker) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		key := b.opts.key(msg)
+		generation, changes, err := b.allow(key)
+		b.report(ctx, l, changes)
+		if err != nil {
+			return nil, err
+		}
+
+		// a handler that panics failed, the result is recorded in a defer so a probe always frees its slot
+		result := resultFailure
+		defer func() {
+			b.report(ctx, l, b.record(key, generation, result))
+		}()
+
+		resp, err := next.Handle(ctx, l, msg)
+		switch {
+		case err == nil:
+			result = resultSuccess
+		case !b.opts.isFailure(err):
+			result = resultIgnored
+		default:
+		}
+		return resp, err
+	})
+}
+
+// State returns the current state of the circuit with the key.
+// A circuit that didn't handle any messages yet is closed.
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// allow decides if a message for the circuit can be handled.
+func (b *CircuitBreaker) allow(key string) (uint64, []CircuitStateChanged, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.clock.Now()
+	c, ok := b.circuits[key]
+	if !ok {
+		b.sweep(now)
+		c = &circuit{since: now, windowStart: now}
+		b.circuits[key] = c
+	}
+
+	var changes []CircuitStateChanged
+	if c.state == CircuitOpen && now.Sub(c.since) >= b.opts.openTimeout {
+		changes = append(changes, b.transition(key, c, CircuitHalfOpen, now))
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		return 0, changes, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if c.probes >= b.opts.probes {
+			return 0, changes, ErrCircuitOpen
+		}
+		c.probes++
+	default:
+		if now.Sub(c.windowStart) >= b.opts.window {
+			c.windowStart, c.total, c.failures = now, 0, 0
+		}
+	}
+	c.active++
+	return c.generation, changes, nil
+}
+
+// record records the result of a message, it returns the state change it caused.
+func (b *CircuitBreaker) record(key string, generation uint64, result circuitResult) []CircuitStateChanged {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+	c.active--
+	if c.generation != generation {
+		// the state changed while the message was handled
+		return nil
+	}
+	now := b.opts.clock.Now()
+
+	switch c.state {
+	case CircuitHalfOpen:
+		switch result {
+		case resultFailure:
+			return []CircuitStateChanged{b.transition(key, c, CircuitOpen, now)}
+		case resultIgnored:
+			// the probe didn't tell if the circuit can be closed, so another message can take its place
+			c.probes--
+			return nil
+		default:
+		}
+		c.successes++
+		if c.successes >= b.opts.probes {
+			return []CircuitStateChanged{b.transition(key, c, CircuitClosed, now)}
+		}
+	case CircuitClosed:
+		if result == resultIgnored {
+			return nil
+		}
+		c.total++
+		if result == resultFailure {
+			c.failures++
+		}
+		if c.total >= b.opts.minVolume && float64(c.failures)/float64(c.total) >= b.opts.failureRatio {
+			return []CircuitStateChanged{b.transition(key, c, CircuitOpen, now)}
+		}
+	default:
+	}
+	return nil
+}
+
+// transition changes the state of the circuit and resets its counts.
+func (b *CircuitBreaker) transition(key string, c *circuit, to CircuitState, now time.Time) CircuitStateChanged {
+	change := CircuitStateChanged{Key: key, From: c.state, To: to, At: now}
+	*c = circuit{
+		state:       to,
+		since:       now,
+		windowStart: now,
+		active:      c.active,
+		generation:  c.generation + 1,
+	}
+	return change
+}
+
+// Len returns the number of circuits, including the unused circuits that weren't removed yet.
+func (b *CircuitBreaker) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.circuits)
+}
+
+// sweep removes the unused circuits once there are enough circuits, the lock has to be held.
+// A closed circuit without messages in its window behaves the same as a new circuit, so it can be removed.
+func (b *CircuitBreaker) sweep(now time.Time) {
+	if len(b.circuits) < b.sweepSize {
+		return
+	}
+	for key, c := range b.circuits {
+		if c.state == CircuitClosed && c.active == 0 && now.Sub(c.windowStart) >= b.opts.window {
+			delete(b.circuits, key)
+		}
+	}
+	b.sweepSize = max(2*len(b.circuits), minCircuitSweepSize)
+}
+
+// report calls the callbacks and publishes the state changes.
+func (b *CircuitBreaker) report(ctx context.Context, l *slog.Logger, changes []CircuitStateChanged) {
+	for _, change := range changes {
+		l.WarnContext(ctx, "circuit "+change.Key+" changed state",
+			slog.String("from", change.From.String()),
+			slog.String("to", change.To.String()))
+
+		for _, onChange := range b.opts.onChange {
+			onChange(ctx, change)
+		}
+
+		p := b.opts.publisher
+		if p == nil {
+			p, _ = mediator.SenderFromContext(ctx).(mediator.Publisher)
+		}
+		if p == nil {
+			continue
+		}
+		// the state changed regardless of the outcome of the message,
+		// so the change shouldn't be queued by a unit of work or stored in an outbox
+		if err := mediator.Publish(ctx, p, change, mediator.WithoutPublishBuffer(), mediator.WithOutbox(nil)); err != nil {
+			l.ErrorContext(ctx, "failed to publish the state change of circuit "+change.Key, slog.Any("error", err))
+		}
+	}
+}
+
+// NewCircuitBreaker creates a new [CircuitBreaker] [mediator.Behavior].
+func NewCircuitBreaker(opt ...CircuitBreakerOption) mediator.Behavior {
+	// default options
+	opts := circuitBreakerOptions{
+		key:          typeKey,
+		failureRatio: 0.5,
+		minVolume:    10,
+		window:       time.Minute,
+		openTimeout:  30 * time.Second,
+		probes:       1,
+		isFailure:    notCancelled,
+		clock:        realClock{},
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &CircuitBreaker{
+		opts:      opts,
+		circuits:  make(map[string]*circuit),
+		sweepSize: minCircuitSweepSize,
+	}
+}
@@ -0,0 +1,315 @@
+package behavior_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+var errDependency = errors.New("dependency is down")
+
+// unreliableKey is the default circuit key of [unreliableQuery].
+const unreliableKey = "github.com/luukvdm/mediator/behavior_test/behavior_test.unreliableQuery"
+
+// unreliableQuery fails when fail is set, or returns err.
+type unreliableQuery struct {
+	fail   bool
+	err    error
+	panics bool
+	region string
+	calls  *int
+}
+
+func (q unreliableQuery) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	if q.calls != nil {
+		*q.calls++
+	}
+	if q.panics {
+		panic("dependency is gone")
+	}
+	if q.fail {
+		return "", errDependency
+	}
+	return "ok", q.err
+}
+
+func sendUnreliable(ctx context.Context, m mediator.Sender, fail bool, times int) error {
+	var err error
+	for range times {
+		_, err = mediator.Send(ctx, m, unreliableQuery{fail: fail})
+	}
+	return err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	var changes []string
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(4),
+		behavior.WithFailureRatio(0.5),
+		behavior.WithOpenTimeout(10*time.Second),
+		behavior.WithHalfOpenProbes(2),
+		behavior.WithCircuitClock(clock),
+		behavior.WithStateChange(func(_ context.Context, change behavior.CircuitStateChanged) {
+			assert.Equal(t, unreliableKey, change.Key)
+			changes = append(changes, change.From.String()+"->"+change.To.String())
+		}),
+	)))
+
+	// the circuit doesn't open before the minimum volume is reached
+	require.NoError(t, sendUnreliable(ctx, m, false, 2))
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	assert.Empty(t, changes)
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	assert.Equal(t, []string{"closed->open"}, changes)
+
+	calls := 0
+	_, err := mediator.Send(ctx, m, unreliableQuery{calls: &calls})
+	require.ErrorIs(t, err, behavior.ErrCircuitOpen)
+	assert.Zero(t, calls, "an open circuit shouldn't handle the request")
+
+	// after the timeout the probes are let through
+	clock.Advance(10 * time.Second)
+	require.NoError(t, sendUnreliable(ctx, m, false, 1))
+	assert.Equal(t, []string{"closed->open", "open->half-open"}, changes)
+	require.NoError(t, sendUnreliable(ctx, m, false, 1))
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, changes)
+}
+
+func TestCircuitBreaker_ProbeFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	var states []behavior.CircuitState
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithOpenTimeout(time.Second),
+		behavior.WithCircuitClock(clock),
+		behavior.WithStateChange(func(_ context.Context, change behavior.CircuitStateChanged) {
+			states = append(states, change.To)
+		}),
+	)))
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	clock.Advance(time.Second)
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	require.ErrorIs(t, sendUnreliable(ctx, m, false, 1), behavior.ErrCircuitOpen)
+
+	assert.Equal(t, []behavior.CircuitState{behavior.CircuitOpen, behavior.CircuitHalfOpen, behavior.CircuitOpen}, states)
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(2),
+		behavior.WithCircuitWindow(time.Minute),
+		behavior.WithCircuitClock(clock),
+	)))
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	clock.Advance(time.Minute)
+	// the failure of the previous window doesn't count
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	require.NoError(t, sendUnreliable(ctx, m, false, 1))
+}
+
+func TestCircuitBreaker_ProbeCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	b := behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithOpenTimeout(10*time.Second),
+		behavior.WithCircuitClock(clock),
+	).(*behavior.CircuitBreaker)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	clock.Advance(10 * time.Second)
+
+	_, err := mediator.Send(ctx, m, unreliableQuery{err: context.Canceled})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, behavior.CircuitHalfOpen, b.State(unreliableKey),
+		"a cancelled probe shouldn't close the circuit")
+
+	require.NoError(t, sendUnreliable(ctx, m, false, 1), "the cancelled probe should free its slot")
+	assert.Equal(t, behavior.CircuitClosed, b.State(unreliableKey))
+}
+
+func TestCircuitBreaker_ProbePanics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	b := behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithOpenTimeout(10*time.Second),
+		behavior.WithCircuitClock(clock),
+	).(*behavior.CircuitBreaker)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	clock.Advance(10 * time.Second)
+
+	assert.Panics(t, func() {
+		_, _ = mediator.Send(ctx, m, unreliableQuery{panics: true})
+	})
+	assert.Equal(t, behavior.CircuitOpen, b.State(unreliableKey), "a probe that panics failed")
+
+	clock.Advance(10 * time.Second)
+	require.NoError(t, sendUnreliable(ctx, m, false, 1), "the probe that panicked should free its slot")
+}
+
+func TestCircuitBreaker_Key(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithCircuitKey(func(msg mediator.Message) string {
+			if q, ok := msg.GetInner().(unreliableQuery); ok {
+				return q.region
+			}
+			return msg.String()
+		}),
+	)))
+
+	_, err := mediator.Send(ctx, m, unreliableQuery{fail: true, region: "eu"})
+	require.ErrorIs(t, err, errDependency)
+	_, err = mediator.Send(ctx, m, unreliableQuery{region: "eu"})
+	require.ErrorIs(t, err, behavior.ErrCircuitOpen)
+
+	_, err = mediator.Send(ctx, m, unreliableQuery{region: "us"})
+	require.NoError(t, err, "other keys should have their own circuit")
+}
+
+func TestCircuitBreaker_PointerRequests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(behavior.WithMinimumVolume(1))))
+
+	_, err := mediator.Send(ctx, m, &unreliableQuery{fail: true})
+	require.ErrorIs(t, err, errDependency)
+	_, err = mediator.Send(ctx, m, &unreliableQuery{})
+	require.ErrorIs(t, err, behavior.ErrCircuitOpen)
+
+	user, err := mediator.Send(ctx, m, &getUser{id: "1"})
+	require.NoError(t, err, "requests of different types shouldn't share a circuit")
+	assert.Equal(t, "user-1", user)
+}
+
+func TestCircuitBreaker_RemovesUnusedCircuits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	b := behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithCircuitWindow(time.Second),
+		behavior.WithCircuitClock(clock),
+		behavior.WithCircuitKey(func(msg mediator.Message) string {
+			return msg.GetInner().(unreliableQuery).region
+		}),
+	).(*behavior.CircuitBreaker)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	_, err := mediator.Send(ctx, m, unreliableQuery{fail: true, region: "down"})
+	require.ErrorIs(t, err, errDependency)
+	for i := range 1000 {
+		_, err = mediator.Send(ctx, m, unreliableQuery{region: fmt.Sprint(i)})
+		require.NoError(t, err)
+		clock.Advance(100 * time.Millisecond)
+	}
+	assert.Less(t, b.Len(), 1000, "unused circuits should be removed")
+	assert.Equal(t, behavior.CircuitOpen, b.State("down"), "open circuits should be kept")
+}
+
+func TestCircuitBreaker_Failure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(
+		behavior.WithMinimumVolume(1),
+		behavior.WithFailure(func(err error) bool {
+			return !errors.Is(err, errDependency)
+		}),
+	)))
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 3), errDependency, "errors that aren't failures shouldn't open the circuit")
+}
+
+func TestCircuitBreaker_PublishesStateChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(behavior.WithMinimumVolume(1))))
+
+	var published []behavior.CircuitStateChanged
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, change behavior.CircuitStateChanged) error {
+		published = append(published, change)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	require.Len(t, published, 1)
+	assert.Equal(t, unreliableKey, published[0].Key)
+	assert.Equal(t, behavior.CircuitClosed, published[0].From)
+	assert.Equal(t, behavior.CircuitOpen, published[0].To)
+}
+
+// rejectingOutbox is a [mediator.Outbox] that can't store any notification.
+type rejectingOutbox struct{}
+
+func (rejectingOutbox) Store(context.Context, any, mediator.Metadata) error {
+	return errors.New("notification isn't registered")
+}
+
+func TestCircuitBreaker_PublishesStateChangesRightAway(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(
+		mediator.WithRequestBehaviors(behavior.NewCircuitBreaker(behavior.WithMinimumVolume(1))),
+		mediator.WithPublishOutbox(rejectingOutbox{}),
+	)
+
+	var published []behavior.CircuitStateChanged
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, change behavior.CircuitStateChanged) error {
+		published = append(published, change)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	ctx, buffer := mediator.ContextWithPublishBuffer(context.Background())
+	require.ErrorIs(t, sendUnreliable(ctx, m, true, 1), errDependency)
+	buffer.Discard()
+	require.Len(t, published, 1, "the state change shouldn't be buffered or stored in the outbox")
+	assert.Equal(t, behavior.CircuitOpen, published[0].To)
+}
+
+func TestCircuitState_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "closed", behavior.CircuitClosed.String())
+	assert.Equal(t, "open", behavior.CircuitOpen.String())
+	assert.Equal(t, "half-open", behavior.CircuitHalfOpen.String())
+	assert.Equal(t, "unknown", behavior.CircuitState(999).String())
+}
@@ -0,0 +1,102 @@
+package behavior
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// ConcurrencyLimit is a [mediator.Behavior] that limits how many messages are handled at the same time.
+	//
+	// Every message type, or key returned by the function of [WithLimitKey], has its own limit.
+	// The limit of a key is removed when none of its messages are handled or waiting.
+	// Messages over the limit wait for a free slot or are rejected, depending on the [LimitMode].
+	// The time a message waited is added to the span in the context as the
+	// `mediator.concurrency_limit.queue_time_ms` attribute, so the behavior should run after the [OtelTracer].
+	ConcurrencyLimit struct {
+		limit      int
+		opts       limitOptions
+		mu         sync.Mutex
+		semaphores map[string]*semaphore
+	}
+
+	semaphore struct {
+		slots chan struct{}
+		// users is the amount of messages that hold or wait for a slot
+		users int
+	}
+)
+
+// Handler runs the [ConcurrencyLimit] behavior.
+func (b *ConcurrencyLimit) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		key := b.opts.key(msg)
+		slots := b.acquire(key)
+		defer b.release(key)
+		start := b.opts.clock.Now()
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			if b.opts.mode == LimitReject {
+				reportQueueTime(ctx, "mediator.concurrency_limit", key, 0, true)
+				l.DebugContext(ctx, "concurrency limit of "+key+" exceeded")
+				return nil, ErrLimitExceeded
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				reportQueueTime(ctx, "mediator.concurrency_limit", key, b.opts.clock.Now().Sub(start), true)
+				return nil, ctx.Err()
+			}
+		}
+		defer func() { <-slots }()
+
+		reportQueueTime(ctx, "mediator.concurrency_limit", key, b.opts.clock.Now().Sub(start), false)
+		return next.Handle(ctx, l, msg)
+	})
+}
+
+// acquire returns the semaphore slots of the key, the message has to [ConcurrencyLimit.release] the key when it is done.
+func (b *ConcurrencyLimit) acquire(key string) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sem, ok := b.semaphores[key]
+	if !ok {
+		sem = &semaphore{slots: make(chan struct{}, b.limit)}
+		b.semaphores[key] = sem
+	}
+	sem.users++
+	return sem.slots
+}
+
+// release removes the semaphore of the key when no more messages use it.
+func (b *ConcurrencyLimit) release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sem := b.semaphores[key]
+	sem.users--
+	if sem.users == 0 {
+		delete(b.semaphores, key)
+	}
+}
+
+// Len returns the number of keys that have messages that are handled or waiting.
+func (b *ConcurrencyLimit) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.semaphores)
+}
+
+// NewConcurrencyLimit creates a new [ConcurrencyLimit] [mediator.Behavior]
+// that handles at most limit messages with the same key at the same time.
+func NewConcurrencyLimit(limit int, opt ...LimitOption) mediator.Behavior {
+	return &ConcurrencyLimit{
+		limit:      max(limit, 1),
+		opts:       newLimitOptions(opt),
+		semaphores: make(map[string]*semaphore),
+	}
+}
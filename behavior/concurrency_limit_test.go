@@ -0,0 +1,171 @@
+package behavior_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+// sendReport blocks until it is released.
+type sendReport struct {
+	started chan<- struct{}
+	release <-chan struct{}
+}
+
+func (r sendReport) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	if r.started != nil {
+		r.started <- struct{}{}
+	}
+	if r.release != nil {
+		<-r.release
+	}
+	return "sent", nil
+}
+
+// startReport sends a blocking report in the background, it returns when the report is being handled.
+func startReport(t *testing.T, m mediator.Sender, options ...mediator.SendOption) (release func()) {
+	t.Helper()
+	started, releaseCh, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := mediator.Send(context.Background(), m, sendReport{started: started, release: releaseCh}, options...)
+		assert.NoError(t, err)
+	}()
+	<-started
+	return func() {
+		close(releaseCh)
+		<-done
+	}
+}
+
+func tenant(name string) mediator.SendOption {
+	return mediator.WithSendMetadata(mediator.Metadata{mediator.MetadataTenant: name})
+}
+
+func TestConcurrencyLimit_Reject(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewConcurrencyLimit(1,
+		behavior.WithLimitMode(behavior.LimitReject),
+		behavior.WithLimitKey(func(msg mediator.Message) string {
+			return msg.String() + "/" + msg.Metadata().Get(mediator.MetadataTenant)
+		}),
+	)))
+
+	release := startReport(t, m, tenant("acme"))
+
+	_, err := mediator.Send(ctx, m, sendReport{}, tenant("acme"))
+	require.ErrorIs(t, err, behavior.ErrLimitExceeded)
+
+	_, err = mediator.Send(ctx, m, sendReport{}, tenant("globex"))
+	require.NoError(t, err, "other tenants should have their own limit")
+
+	release()
+	_, err = mediator.Send(ctx, m, sendReport{}, tenant("acme"))
+	require.NoError(t, err, "the slot should be freed when the report is handled")
+}
+
+func TestConcurrencyLimit_PointerRequests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewConcurrencyLimit(1, behavior.WithLimitMode(behavior.LimitReject))))
+
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		_, err := mediator.Send(ctx, m, &sendReport{started: started, release: release})
+		done <- err
+	}()
+	<-started
+
+	_, err := mediator.Send(ctx, m, &sendReport{})
+	require.ErrorIs(t, err, behavior.ErrLimitExceeded)
+
+	user, err := mediator.Send(ctx, m, &getUser{id: "1"})
+	require.NoError(t, err, "requests of different types shouldn't share a limit")
+	assert.Equal(t, "user-1", user)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestConcurrencyLimit_Wait(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdkTrace.NewTracerProvider(sdkTrace.WithSyncer(exporter))
+	clock := &fakeClock{now: time.Now()}
+	m := mediator.New(mediator.WithRequestBehaviors(
+		behavior.NewOtelTracer(behavior.WithTracerProvider(provider)),
+		behavior.NewConcurrencyLimit(1, behavior.WithLimitClock(clock)),
+	))
+
+	release := startReport(t, m)
+
+	done := make(chan error)
+	go func() {
+		_, err := mediator.Send(context.Background(), m, sendReport{})
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		require.Fail(t, "the report should wait for a free slot")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(1500 * time.Millisecond)
+	release()
+	require.NoError(t, <-done)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes, attribute.Int64("mediator.concurrency_limit.queue_time_ms", 0))
+	assert.Contains(t, spans[1].Attributes, attribute.Int64("mediator.concurrency_limit.queue_time_ms", 1500))
+	assert.Contains(t, spans[1].Attributes, attribute.String("mediator.concurrency_limit.key", "github.com/luukvdm/mediator/behavior_test/behavior_test.sendReport"))
+}
+
+func TestConcurrencyLimit_WaitCancelled(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewConcurrencyLimit(1)))
+	release := startReport(t, m)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := mediator.Send(ctx, m, sendReport{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestConcurrencyLimit_RemovesUnusedLimits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	limit := behavior.NewConcurrencyLimit(1, behavior.WithLimitKey(func(msg mediator.Message) string {
+		return msg.Metadata().Get(mediator.MetadataTenant)
+	})).(*behavior.ConcurrencyLimit)
+	m := mediator.New(mediator.WithRequestBehaviors(limit))
+
+	release := startReport(t, m, tenant("acme"))
+	for i := range 100 {
+		_, err := mediator.Send(ctx, m, sendReport{}, tenant(fmt.Sprint(i)))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, limit.Len(), "only the limit of the report that is handled should be kept")
+
+	release()
+	assert.Zero(t, limit.Len())
+}
@@ -0,0 +1,22 @@
+package behavior
+
+// Waiters returns the amount of callers that wait for the response of a message that is handled once for all of them.
+func (b *Singleflight) Waiters() int {
+	return b.flights.waiters()
+}
+
+// Waiters returns the amount of callers that wait for the response of a message that missed the cache.
+func (b *Cache) Waiters() int {
+	return b.flights.waiters()
+}
+
+// waiters returns the amount of callers that wait for a flight of the group.
+func (g *flightGroup) waiters() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := 0
+	for _, f := range g.flights {
+		n += f.waiters
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+package behavior
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// flightGroup handles a message once for all concurrent callers with the same key.
+	flightGroup struct {
+		mu      sync.Mutex
+		flights map[string]*flight
+	}
+
+	flight struct {
+		done chan struct{}
+		resp any
+		err  error
+		// panicked holds the value of a panic of the handler, it is rethrown for every caller
+		panicked any
+		// waiters is the amount of callers that are waiting for the flight
+		waiters int
+		cancel  context.CancelFunc
+	}
+)
+
+// do calls handle, or waits for the call with the same key that is in progress.
+// It reports if the result is shared with another caller.
+//
+// handle runs with a context that is only cancelled when every caller stopped waiting,
+// so one caller that cancels doesn't fail the others.
+func (g *flightGroup) do(ctx context.Context, key string, handle func(ctx context.Context) (any, error)) (any, bool, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, shared := g.flights[key]
+	if !shared {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, handle)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.panicked != nil {
+			panic(f.panicked)
+		}
+		return f.resp, shared, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// nobody is interested in the result anymore
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, handle func(ctx context.Context) (any, error)) {
+	defer func() {
+		f.panicked = recover()
+		f.cancel()
+		g.mu.Lock()
+		g.forget(key, f)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.resp, f.err = handle(ctx)
+}
+
+// forget removes the flight, unless it was already replaced by a new one.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// typeScopedKey prefixes the key with the full type of the message, so equal keys of different types don't collide.
+func typeScopedKey(msg mediator.Message, key string) string {
+	return typeKey(msg) + ":" + key
+}
+
+// typeKey returns the full type of the message, including its package path.
+// [mediator.Message.String] can't be used, because it is empty for pointer types.
+func typeKey(msg mediator.Message) string {
+	t := reflect.TypeOf(msg.GetInner())
+	if t == nil {
+		return msg.String()
+	}
+	base := t
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	return base.PkgPath() + "/" + t.String()
+}
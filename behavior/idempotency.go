@@ -0,0 +1,203 @@
+package behavior
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+// MetadataIdempotencyKey is the [mediator.Metadata] key that the [Idempotency] behavior reads the idempotency key from,
+// when the request doesn't implement [Idempotent].
+const MetadataIdempotencyKey = "idempotency_key"
+
+// ErrIdempotencyInProgress is returned by the [Idempotency] behavior
+// when a request with the same idempotency key is still being handled.
+var ErrIdempotencyInProgress = errors.New("a request with the same idempotency key is in progress")
+
+// ErrIdempotencyLockLost is returned by an [IdempotencyStore] when a response is completed by a caller
+// that doesn't hold the lock of the key anymore, because the lock expired and was taken by another request.
+var ErrIdempotencyLockLost = errors.New("the idempotency key is locked by another request")
+
+type (
+	// Idempotent is implemented by requests that carry their own idempotency key.
+	Idempotent interface {
+		// IdempotencyKey identifies the request, keys only have to be unique per request type.
+		IdempotencyKey() string
+	}
+
+	// IdempotencyStore stores the outcome of the requests of the [Idempotency] behavior.
+	// Every lock has an owner, a random token of the request that holds it,
+	// so a request whose lock expired can't complete or unlock the key of another request.
+	// It has to be safe for concurrent use.
+	IdempotencyStore interface {
+		// Lock locks the key for the owner for the duration of the timeout, so concurrent requests with the key are rejected.
+		// If the key was completed, its response is returned and completed is true.
+		// [ErrIdempotencyInProgress] is returned if the key is locked.
+		Lock(ctx context.Context, key, owner string, timeout time.Duration) (response []byte, completed bool, err error)
+		// Complete stores the response of the key, it is kept for the duration of the retention.
+		// [ErrIdempotencyLockLost] is returned if the key is locked or completed by another owner.
+		Complete(ctx context.Context, key, owner string, response []byte, retention time.Duration) error
+		// Unlock removes the lock of the owner, so the request can be handled again.
+		// Locks of other owners and completed keys are left alone.
+		Unlock(ctx context.Context, key, owner string) error
+	}
+
+	// Idempotency is a [mediator.Behavior] that handles a request with the same idempotency key only once.
+	//
+	// The key is taken from [Idempotent] requests, or from the [MetadataIdempotencyKey] metadata.
+	// The response of the first request is stored as JSON in the [IdempotencyStore],
+	// repeated requests get the stored response without being handled.
+	// A request that fails isn't stored, so it can be retried.
+	// Stream requests are handled as usual.
+	// Requests with the key that arrive while the first one is handled are rejected with [ErrIdempotencyInProgress].
+	Idempotency struct {
+		opts idempotencyOptions
+	}
+
+	// IdempotencyOption defines the method to customize [NewIdempotency].
+	IdempotencyOption  func(*idempotencyOptions)
+	idempotencyOptions struct {
+		store       IdempotencyStore
+		key         func(msg mediator.Message) string
+		lockTimeout time.Duration
+		retention   time.Duration
+	}
+)
+
+// WithIdempotencyStore sets the [IdempotencyStore] that holds the outcomes.
+// The default is a [MemoryIdempotencyStore].
+func WithIdempotencyStore(store IdempotencyStore) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.store = store
+	}
+}
+
+// WithIdempotencyKey sets the function that returns the idempotency key of a message.
+// An empty key means the message isn't deduplicated.
+func WithIdempotencyKey(key func(msg mediator.Message) string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.key = key
+	}
+}
+
+// WithLockTimeout sets how long a key stays locked while its request is handled,
+// so a key isn't locked forever when the process stops while handling it.
+// The default is 1 minute.
+func WithLockTimeout(timeout time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lockTimeout = timeout
+	}
+}
+
+// WithRetention sets how long the outcome of a key is stored.
+// The default is 24 hours.
+func WithRetention(retention time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.retention = retention
+	}
+}
+
+// Handler runs the [Idempotency] behavior.
+func (b *Idempotency) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		key := b.opts.key(msg)
+		// the results of a stream are yielded to its caller only, so they can't be replayed
+		if key == "" || mediator.IsStream(msg) {
+			return next.Handle(ctx, l, msg)
+		}
+		key = typeScopedKey(msg, key)
+		owner := newLockOwner()
+
+		stored, completed, err := b.opts.store.Lock(ctx, key, owner, b.opts.lockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if completed {
+			l.DebugContext(ctx, "replaying the response of "+msg.String(), slog.String(MetadataIdempotencyKey, key))
+			return decodeResponse(msg, stored)
+		}
+
+		resp, err := next.Handle(ctx, l, msg)
+		if err == nil {
+			storeErr := b.complete(ctx, key, owner, resp)
+			if storeErr == nil {
+				return resp, nil
+			}
+			l.ErrorContext(ctx, "failed to store the response of "+msg.String(), slog.Any("error", storeErr))
+		}
+
+		// the lock has to be removed, even if the request was cancelled
+		if err := b.opts.store.Unlock(context.WithoutCancel(ctx), key, owner); err != nil {
+			l.ErrorContext(ctx, "failed to unlock "+key, slog.Any("error", err))
+		}
+		return resp, err
+	})
+}
+
+// complete stores the response of the key as JSON.
+func (b *Idempotency) complete(ctx context.Context, key, owner string, resp any) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return b.opts.store.Complete(ctx, key, owner, data, b.opts.retention)
+}
+
+// newLockOwner generates a random token that identifies the holder of a lock.
+func newLockOwner() string {
+	var token [16]byte
+	_, _ = rand.Read(token[:])
+	return hex.EncodeToString(token[:])
+}
+
+// decodeResponse decodes the stored response into the response type of the message.
+func decodeResponse(msg mediator.Message, data []byte) (any, error) {
+	zero := mediator.ZeroResponse(msg)
+	if zero == nil {
+		var resp any
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("failed to decode the stored response of %s: %w", msg.String(), err)
+		}
+		return resp, nil
+	}
+	resp := reflect.New(reflect.TypeOf(zero))
+	if err := json.Unmarshal(data, resp.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode the stored response of %s: %w", msg.String(), err)
+	}
+	return resp.Elem().Interface(), nil
+}
+
+// idempotencyKey returns the key of [Idempotent] requests, or the key in the metadata of the message.
+func idempotencyKey(msg mediator.Message) string {
+	if i, ok := msg.GetInner().(Idempotent); ok {
+		return i.IdempotencyKey()
+	}
+	return msg.Metadata().Get(MetadataIdempotencyKey)
+}
+
+// NewIdempotency creates a new [Idempotency] [mediator.Behavior].
+func NewIdempotency(opt ...IdempotencyOption) mediator.Behavior {
+	// default options
+	opts := idempotencyOptions{
+		key:         idempotencyKey,
+		lockTimeout: time.Minute,
+		retention:   24 * time.Hour,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if opts.store == nil {
+		opts.store = NewMemoryIdempotencyStore()
+	}
+	return &Idempotency{
+		opts: opts,
+	}
+}
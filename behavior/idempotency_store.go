@@ -0,0 +1,272 @@
+package behavior
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// idempotencySweepInterval is how often the [FileIdempotencyStore] removes expired records.
+	idempotencySweepInterval = time.Minute
+	// minIdempotencySweepSize is the number of records the [MemoryIdempotencyStore] holds before it removes expired records.
+	minIdempotencySweepSize = 64
+)
+
+type (
+	// idempotencyRecord is the state of an idempotency key.
+	idempotencyRecord struct {
+		Key       string    `json:"key"`
+		Owner     string    `json:"owner"`
+		Completed bool      `json:"completed"`
+		Response  []byte    `json:"response,omitempty"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	// MemoryIdempotencyStore is an [IdempotencyStore] that keeps the outcomes in memory.
+	// Expired outcomes are removed whenever the number of records doubled since they were last removed,
+	// so the store only grows with the outcomes that didn't expire.
+	MemoryIdempotencyStore struct {
+		mu        sync.Mutex
+		records   map[string]idempotencyRecord
+		sweepSize int
+	}
+
+	// FileIdempotencyStore is an [IdempotencyStore] that keeps every outcome in a JSON file in a directory,
+	// so the outcomes survive restarts.
+	// Expired outcomes are removed at most once per minute, when the store is used.
+	// The store locks within the process, a directory shouldn't be shared by multiple processes.
+	FileIdempotencyStore struct {
+		dir       string
+		mu        sync.Mutex
+		nextSweep time.Time
+	}
+)
+
+// lock locks the record for the owner, the returned record has to be saved.
+func (r idempotencyRecord) lock(key, owner string, now time.Time, timeout time.Duration) (idempotencyRecord, []byte, bool, error) {
+	if r.Key != "" && !r.expired(now) {
+		if r.Completed {
+			return r, r.Response, true, nil
+		}
+		return r, nil, false, ErrIdempotencyInProgress
+	}
+	return idempotencyRecord{Key: key, Owner: owner, ExpiresAt: now.Add(timeout)}, nil, false, nil
+}
+
+// complete returns the completed record, or [ErrIdempotencyLockLost] if another owner holds the record.
+func (r idempotencyRecord) complete(key, owner string, response []byte, now time.Time, retention time.Duration) (idempotencyRecord, error) {
+	if r.Key != "" && r.Owner != owner && !r.expired(now) {
+		return r, ErrIdempotencyLockLost
+	}
+	return idempotencyRecord{Key: key, Owner: owner, Completed: true, Response: response, ExpiresAt: now.Add(retention)}, nil
+}
+
+// unlockable reports if the record is a lock of the owner.
+func (r idempotencyRecord) unlockable(owner string) bool {
+	return r.Key != "" && !r.Completed && r.Owner == owner
+}
+
+func (r idempotencyRecord) expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Lock implements [IdempotencyStore].
+func (s *MemoryIdempotencyStore) Lock(_ context.Context, key, owner string, timeout time.Duration) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	r, resp, completed, err := s.records[key].lock(key, owner, now, timeout)
+	if err == nil && !completed {
+		s.records[key] = r
+		s.sweep(now)
+	}
+	return resp, completed, err
+}
+
+// Complete implements [IdempotencyStore].
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key, owner string, response []byte, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.records[key].complete(key, owner, response, time.Now(), retention)
+	if err != nil {
+		return err
+	}
+	s.records[key] = r
+	return nil
+}
+
+// Unlock implements [IdempotencyStore].
+func (s *MemoryIdempotencyStore) Unlock(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[key].unlockable(owner) {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+// Len returns the number of records in the store, including the expired records that weren't removed yet.
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// sweep removes the expired records once the store has grown enough, the lock has to be held.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if len(s.records) < s.sweepSize {
+		return
+	}
+	for key, r := range s.records {
+		if r.expired(now) {
+			delete(s.records, key)
+		}
+	}
+	s.sweepSize = max(2*len(s.records), minIdempotencySweepSize)
+}
+
+// NewMemoryIdempotencyStore creates a new [MemoryIdempotencyStore].
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records:   make(map[string]idempotencyRecord),
+		sweepSize: minIdempotencySweepSize,
+	}
+}
+
+// Lock implements [IdempotencyStore].
+func (s *FileIdempotencyStore) Lock(_ context.Context, key, owner string, timeout time.Duration) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	r, err := s.read(key)
+	if err != nil {
+		return nil, false, err
+	}
+	r, resp, completed, err := r.lock(key, owner, now, timeout)
+	if err == nil && !completed {
+		err = s.write(r)
+	}
+	return resp, completed, err
+}
+
+// Complete implements [IdempotencyStore].
+func (s *FileIdempotencyStore) Complete(_ context.Context, key, owner string, response []byte, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.read(key)
+	if err != nil {
+		return err
+	}
+	r, err = r.complete(key, owner, response, time.Now(), retention)
+	if err != nil {
+		return err
+	}
+	return s.write(r)
+}
+
+// Unlock implements [IdempotencyStore].
+func (s *FileIdempotencyStore) Unlock(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.read(key)
+	if err != nil || !r.unlockable(owner) {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// sweep removes the files of expired records, the lock has to be held.
+// Files that can't be read are left alone, a failed sweep is retried with the next sweep.
+func (s *FileIdempotencyStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(idempotencySweepInterval)
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		r, err := readRecord(path)
+		if err == nil && r.expired(now) {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// path returns the file of the key, the key is hashed so it can be used as a file name.
+func (s *FileIdempotencyStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read returns the record of the key, or an empty record if there is none.
+func (s *FileIdempotencyStore) read(key string) (idempotencyRecord, error) {
+	r, err := readRecord(s.path(key))
+	if err != nil {
+		return r, fmt.Errorf("failed to read the idempotency record of %s: %w", key, err)
+	}
+	return r, nil
+}
+
+// readRecord reads the record in the file, or returns an empty record if the file doesn't exist.
+func readRecord(path string) (idempotencyRecord, error) {
+	var r idempotencyRecord
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+// write writes the record to a temporary file first, so a record is never partially written.
+func (s *FileIdempotencyStore) write(r idempotencyRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(r.Key))
+}
+
+// NewFileIdempotencyStore creates a new [FileIdempotencyStore] that keeps its files in the directory.
+// The directory is created if it doesn't exist.
+func NewFileIdempotencyStore(dir string) (*FileIdempotencyStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the idempotency store directory: %w", err)
+	}
+	return &FileIdempotencyStore{dir: dir}, nil
+}
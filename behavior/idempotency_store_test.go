@@ -0,0 +1,116 @@
+package behavior_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator/behavior"
+)
+
+func testIdempotencyStore(t *testing.T, store behavior.IdempotencyStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, completed, err := store.Lock(ctx, "a", "first", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	_, _, err = store.Lock(ctx, "a", "second", time.Minute)
+	require.ErrorIs(t, err, behavior.ErrIdempotencyInProgress)
+
+	require.NoError(t, store.Unlock(ctx, "a", "second"))
+	_, _, err = store.Lock(ctx, "a", "second", time.Minute)
+	require.ErrorIs(t, err, behavior.ErrIdempotencyInProgress, "only the owner should unlock a key")
+
+	require.NoError(t, store.Unlock(ctx, "a", "first"))
+	_, _, err = store.Lock(ctx, "a", "second", time.Minute)
+	require.NoError(t, err, "an unlocked key should be locked again")
+
+	require.ErrorIs(t, store.Complete(ctx, "a", "first", []byte(`"stale"`), time.Minute), behavior.ErrIdempotencyLockLost)
+	require.NoError(t, store.Complete(ctx, "a", "second", []byte(`"done"`), time.Minute))
+	require.NoError(t, store.Unlock(ctx, "a", "second"), "unlocking a completed key should keep its response")
+	resp, completed, err := store.Lock(ctx, "a", "third", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.Equal(t, `"done"`, string(resp))
+
+	_, _, err = store.Lock(ctx, "b", "first", time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, store.Complete(ctx, "c", "first", []byte(`"done"`), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, completed, err = store.Lock(ctx, "b", "second", time.Minute)
+	require.NoError(t, err, "an expired lock should be released")
+	assert.False(t, completed)
+	_, completed, err = store.Lock(ctx, "c", "second", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, completed, "an expired response should be removed")
+
+	require.NoError(t, store.Unlock(ctx, "b", "first"))
+	_, _, err = store.Lock(ctx, "b", "third", time.Minute)
+	require.ErrorIs(t, err, behavior.ErrIdempotencyInProgress, "the owner of an expired lock shouldn't unlock the new lock")
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	testIdempotencyStore(t, behavior.NewMemoryIdempotencyStore())
+}
+
+func TestMemoryIdempotencyStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := behavior.NewMemoryIdempotencyStore()
+	for i := range 1000 {
+		_, _, err := store.Lock(ctx, fmt.Sprint(i), "owner", time.Nanosecond)
+		require.NoError(t, err)
+		time.Sleep(time.Microsecond)
+	}
+	assert.Less(t, store.Len(), 1000, "expired records should be removed")
+}
+
+func TestFileIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := behavior.NewFileIdempotencyStore(dir)
+	require.NoError(t, err)
+	testIdempotencyStore(t, store)
+
+	// the outcomes should survive a restart
+	ctx := context.Background()
+	require.NoError(t, store.Complete(ctx, "restart", "owner", []byte(`{"number":1}`), time.Minute))
+	reopened, err := behavior.NewFileIdempotencyStore(dir)
+	require.NoError(t, err)
+	resp, completed, err := reopened.Lock(ctx, "restart", "other", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.JSONEq(t, `{"number":1}`, string(resp))
+}
+
+func TestFileIdempotencyStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := behavior.NewFileIdempotencyStore(dir)
+	require.NoError(t, err)
+	for i := range 10 {
+		require.NoError(t, store.Complete(ctx, fmt.Sprint(i), "owner", []byte(`"done"`), time.Millisecond))
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	reopened, err := behavior.NewFileIdempotencyStore(dir)
+	require.NoError(t, err)
+	_, _, err = reopened.Lock(ctx, "new", "owner", time.Minute)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "only the new lock should be left")
+}
@@ -0,0 +1,173 @@
+package behavior_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+type (
+	// placeOrder is an idempotent request that creates an order.
+	placeOrder struct {
+		requestID string
+		item      string
+		calls     *int
+		err       error
+		started   chan<- struct{}
+		release   <-chan struct{}
+	}
+
+	order struct {
+		Number int    `json:"number"`
+		Item   string `json:"item"`
+	}
+
+	// cancelOrder is a command that gets its idempotency key from the metadata.
+	cancelOrder struct {
+		calls *int
+	}
+)
+
+func (r placeOrder) Handle(_ context.Context, _ *slog.Logger) (order, error) {
+	*r.calls++
+	if r.started != nil {
+		close(r.started)
+		<-r.release
+	}
+	if r.err != nil {
+		return order{}, r.err
+	}
+	return order{Number: *r.calls, Item: r.item}, nil
+}
+
+func (r placeOrder) IdempotencyKey() string {
+	return r.requestID
+}
+
+func (c cancelOrder) Handle(_ context.Context, _ *slog.Logger) error {
+	*c.calls++
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewIdempotency()))
+
+	calls := 0
+	first, err := mediator.Send(ctx, m, placeOrder{requestID: "a", item: "gopher plush", calls: &calls})
+	require.NoError(t, err)
+	repeated, err := mediator.Send(ctx, m, placeOrder{requestID: "a", item: "gopher plush", calls: &calls})
+	require.NoError(t, err)
+	assert.Equal(t, first, repeated, "the stored response should be returned")
+	assert.Equal(t, 1, calls)
+
+	other, err := mediator.Send(ctx, m, placeOrder{requestID: "b", item: "gopher mug", calls: &calls})
+	require.NoError(t, err)
+	assert.Equal(t, order{Number: 2, Item: "gopher mug"}, other)
+}
+
+func TestIdempotency_Metadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithCommandBehaviors(behavior.NewIdempotency()))
+
+	calls := 0
+	for range 2 {
+		err := mediator.Dispatch(ctx, m, cancelOrder{calls: &calls},
+			mediator.WithSendMetadata(mediator.Metadata{behavior.MetadataIdempotencyKey: "a"}))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, mediator.Dispatch(ctx, m, cancelOrder{calls: &calls}))
+	require.NoError(t, mediator.Dispatch(ctx, m, cancelOrder{calls: &calls}))
+	assert.Equal(t, 3, calls, "commands without a key should always be handled")
+}
+
+func TestIdempotency_Failure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewIdempotency()))
+
+	calls := 0
+	reqErr := errors.New("out of stock")
+	_, err := mediator.Send(ctx, m, placeOrder{requestID: "a", calls: &calls, err: reqErr})
+	require.ErrorIs(t, err, reqErr)
+
+	_, err = mediator.Send(ctx, m, placeOrder{requestID: "a", calls: &calls})
+	require.NoError(t, err, "a failed request should be handled again")
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewIdempotency()))
+
+	calls := 0
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := mediator.Send(ctx, m, placeOrder{requestID: "a", calls: &calls, started: started, release: release})
+		done <- err
+	}()
+	<-started
+
+	_, err := mediator.Send(ctx, m, placeOrder{requestID: "a", calls: new(int)})
+	require.ErrorIs(t, err, behavior.ErrIdempotencyInProgress)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func (q *getUser) IdempotencyKey() string {
+	return q.id
+}
+
+func (q *getOrder) IdempotencyKey() string {
+	return q.id
+}
+
+func TestIdempotency_PointerRequests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewIdempotency()))
+
+	user, err := mediator.Send(ctx, m, &getUser{id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", user)
+
+	order, err := mediator.Send(ctx, m, &getOrder{id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", order, "requests of different types shouldn't share a key")
+}
+
+func (q gopherStream) IdempotencyKey() string {
+	return "all"
+}
+
+func TestIdempotency_Stream(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewIdempotency()))
+
+	calls := &atomic.Int32{}
+	for range 2 {
+		assert.Equal(t, []string{"Gus", "Gert", "Gopher"}, collectStream(t, m, gopherStream{calls: calls}))
+	}
+	assert.Equal(t, int32(2), calls.Load(), "streams shouldn't be replayed")
+}
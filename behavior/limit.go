@@ -0,0 +1,88 @@
+package behavior
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/luukvdm/mediator"
+)
+
+// LimitMode decides what the [ConcurrencyLimit] and [RateLimit] behaviors do with a message that is over the limit.
+type LimitMode int
+
+const (
+	// LimitWait makes the message wait until it is within the limit, or until its context is done.
+	LimitWait LimitMode = iota
+	// LimitReject rejects the message with [ErrLimitExceeded].
+	LimitReject
+)
+
+// minLimitSweepSize is the number of buckets the [RateLimit] holds before it removes the full buckets.
+const minLimitSweepSize = 64
+
+// ErrLimitExceeded is returned by the [ConcurrencyLimit] and [RateLimit] behaviors when a message is rejected.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+type (
+	// LimitOption defines the method to customize [NewConcurrencyLimit] and [NewRateLimit].
+	LimitOption  func(*limitOptions)
+	limitOptions struct {
+		key   func(msg mediator.Message) string
+		mode  LimitMode
+		clock Clock
+	}
+)
+
+// WithLimitKey sets the function that decides which limit a message counts towards.
+// It can use the type, the name or the [mediator.Metadata] of the message, like the tenant.
+// By default, every message type has its own limit.
+func WithLimitKey(key func(msg mediator.Message) string) LimitOption {
+	return func(o *limitOptions) {
+		o.key = key
+	}
+}
+
+// WithLimitMode sets what happens with messages that are over the limit.
+// The default is [LimitWait].
+func WithLimitMode(mode LimitMode) LimitOption {
+	return func(o *limitOptions) {
+		o.mode = mode
+	}
+}
+
+// WithLimitClock replaces the [Clock] that is used to wait and to measure the queue time, this is useful in tests.
+func WithLimitClock(clock Clock) LimitOption {
+	return func(o *limitOptions) {
+		o.clock = clock
+	}
+}
+
+func newLimitOptions(opt []LimitOption) limitOptions {
+	// default options
+	opts := limitOptions{
+		key:   typeKey,
+		mode:  LimitWait,
+		clock: realClock{},
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return opts
+}
+
+// reportQueueTime adds the time a message waited for the limit to the span in the context.
+func reportQueueTime(ctx context.Context, name, key string, queued time.Duration, rejected bool) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attribute.String(name+".key", key),
+		attribute.Int64(name+".queue_time_ms", queued.Milliseconds()),
+		attribute.Bool(name+".rejected", rejected),
+	)
+}
@@ -3,10 +3,13 @@ package behavior
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"slices"
 
 	"go.opentelemetry.io/otel/trace"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
 
@@ -19,6 +22,7 @@ type (
 	// OtelTracer is a [mediator.Behavior] that adds tracing to the chain.
 	//
 	// The behavior creates a new span for every request that passes through it and adds it to the context.
+	// The [mediator.Metadata] of the message is added to the span as `mediator.metadata.<key>` attributes.
 	// It will also adjust the status and add an error attribute to the span if the resulting error of the request is not nil.
 	OtelTracer struct {
 		tracer trace.Tracer
@@ -41,7 +45,7 @@ func WithTracerProvider(provider trace.TracerProvider) OtelTracerOption {
 // Handler runs the [OtelTracer] behavior.
 func (b *OtelTracer) Handler(next mediator.Handler) mediator.Handler {
 	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
-		spanCtx, span := b.tracer.Start(ctx, msg.String())
+		spanCtx, span := b.tracer.Start(ctx, msg.String(), trace.WithAttributes(metadataAttributes(msg.Metadata())...))
 		defer span.End()
 
 		resp, err := next.Handle(spanCtx, l, msg)
@@ -55,6 +59,18 @@ func (b *OtelTracer) Handler(next mediator.Handler) mediator.Handler {
 	})
 }
 
+// metadataAttributes converts the metadata to span attributes, sorted by key.
+func metadataAttributes(md mediator.Metadata) []attribute.KeyValue {
+	if len(md) == 0 {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(md))
+	for _, k := range slices.Sorted(maps.Keys(md)) {
+		attrs = append(attrs, attribute.String("mediator.metadata."+k, md[k]))
+	}
+	return attrs
+}
+
 // NewOtelTracer creates a new [OtelTracer] [mediator.Behavior].
 func NewOtelTracer(opt ...OtelTracerOption) mediator.Behavior {
 	// default options
@@ -0,0 +1,139 @@
+package behavior
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// RateLimit is a [mediator.Behavior] that limits how many messages are handled per second, using a token bucket.
+	//
+	// Every message type, or key returned by the function of [WithLimitKey], has its own bucket.
+	// A bucket holds up to burst tokens and is refilled with rate tokens per second, every message takes one token.
+	// Buckets that are full are removed whenever the number of buckets doubled since they were last removed.
+	// Messages without a token wait for one or are rejected, depending on the [LimitMode].
+	// The time a message waited is added to the span in the context as the
+	// `mediator.rate_limit.queue_time_ms` attribute, so the behavior should run after the [OtelTracer].
+	RateLimit struct {
+		rate    float64
+		burst   int
+		opts    limitOptions
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		// sweepSize is the number of buckets at which the full buckets are removed
+		sweepSize int
+	}
+
+	bucket struct {
+		// tokens is negative when messages are waiting for tokens
+		tokens float64
+		last   time.Time
+	}
+)
+
+// Handler runs the [RateLimit] behavior.
+func (b *RateLimit) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		key := b.opts.key(msg)
+		wait, ok := b.take(key)
+		if !ok {
+			reportQueueTime(ctx, "mediator.rate_limit", key, 0, true)
+			l.DebugContext(ctx, "rate limit of "+key+" exceeded")
+			return nil, ErrLimitExceeded
+		}
+
+		if wait > 0 {
+			select {
+			case <-b.opts.clock.After(wait):
+			case <-ctx.Done():
+				b.giveBack(key)
+				reportQueueTime(ctx, "mediator.rate_limit", key, wait, true)
+				return nil, ctx.Err()
+			}
+		}
+
+		reportQueueTime(ctx, "mediator.rate_limit", key, wait, false)
+		return next.Handle(ctx, l, msg)
+	})
+}
+
+// take takes a token from the bucket of the key.
+// It returns how long the message has to wait for the token, or false if the message is rejected.
+func (b *RateLimit) take(key string) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.clock.Now()
+	bu, ok := b.buckets[key]
+	if !ok {
+		b.sweep(now)
+		bu = &bucket{tokens: float64(b.burst), last: now}
+		b.buckets[key] = bu
+	}
+	if elapsed := now.Sub(bu.last); elapsed > 0 {
+		bu.tokens = min(bu.tokens+elapsed.Seconds()*b.rate, float64(b.burst))
+		bu.last = now
+	}
+
+	if bu.tokens >= 1 {
+		bu.tokens--
+		return 0, true
+	}
+	if b.opts.mode == LimitReject {
+		return 0, false
+	}
+	// reserve the next token
+	bu.tokens--
+	return time.Duration(-bu.tokens / b.rate * float64(time.Second)), true
+}
+
+// giveBack returns a reserved token to the bucket of the key.
+func (b *RateLimit) giveBack(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// a bucket that was removed was full already
+	if bu, ok := b.buckets[key]; ok {
+		bu.tokens++
+	}
+}
+
+// Len returns the number of buckets, including the full buckets that weren't removed yet.
+func (b *RateLimit) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buckets)
+}
+
+// sweep removes the full buckets once there are enough buckets, the lock has to be held.
+// A full bucket behaves the same as a new bucket, so it can be removed.
+func (b *RateLimit) sweep(now time.Time) {
+	if len(b.buckets) < b.sweepSize {
+		return
+	}
+	for key, bu := range b.buckets {
+		if bu.tokens+now.Sub(bu.last).Seconds()*b.rate >= float64(b.burst) {
+			delete(b.buckets, key)
+		}
+	}
+	b.sweepSize = max(2*len(b.buckets), minLimitSweepSize)
+}
+
+// NewRateLimit creates a new [RateLimit] [mediator.Behavior]
+// that handles rate messages with the same key per second, with bursts of up to burst messages.
+// A rate that isn't positive is set to 1 message per second, a burst below 1 is set to 1.
+func NewRateLimit(rate float64, burst int, opt ...LimitOption) mediator.Behavior {
+	if !(rate > 0) {
+		rate = 1
+	}
+	return &RateLimit{
+		rate:      rate,
+		burst:     max(burst, 1),
+		opts:      newLimitOptions(opt),
+		buckets:   make(map[string]*bucket),
+		sweepSize: minLimitSweepSize,
+	}
+}
@@ -0,0 +1,122 @@
+package behavior_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+func TestRateLimit_Reject(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRateLimit(1, 2,
+		behavior.WithLimitMode(behavior.LimitReject),
+		behavior.WithLimitClock(clock),
+	)))
+
+	for range 2 {
+		_, err := mediator.Send(ctx, m, sendReport{})
+		require.NoError(t, err, "the burst should be let through")
+	}
+	_, err := mediator.Send(ctx, m, sendReport{})
+	require.ErrorIs(t, err, behavior.ErrLimitExceeded)
+
+	clock.Advance(time.Second)
+	_, err = mediator.Send(ctx, m, sendReport{})
+	require.NoError(t, err, "the bucket should be refilled")
+	_, err = mediator.Send(ctx, m, sendReport{})
+	require.ErrorIs(t, err, behavior.ErrLimitExceeded)
+}
+
+func TestRateLimit_Wait(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRateLimit(2, 1, behavior.WithLimitClock(clock))))
+
+	for range 3 {
+		_, err := mediator.Send(ctx, m, sendReport{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, clock.Waited())
+}
+
+func TestRateLimit_InvalidRate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		clock := &fakeClock{now: time.Now()}
+		m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRateLimit(rate, 1, behavior.WithLimitClock(clock))))
+
+		for range 2 {
+			_, err := mediator.Send(ctx, m, sendReport{})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []time.Duration{time.Second}, clock.Waited(), "a rate of %v should be set to 1 per second", rate)
+	}
+}
+
+func TestRateLimit_WaitCancelled(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now(), block: true}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRateLimit(1, 1,
+		behavior.WithLimitClock(clock),
+		behavior.WithLimitKey(func(msg mediator.Message) string {
+			return msg.Metadata().Get(mediator.MetadataTenant)
+		}),
+	)))
+
+	_, err := mediator.Send(context.Background(), m, sendReport{}, tenant("acme"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = mediator.Send(ctx, m, sendReport{}, tenant("acme"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = mediator.Send(context.Background(), m, sendReport{}, tenant("globex"))
+	require.NoError(t, err, "other tenants should have their own bucket")
+
+	clock.block = false
+	clock.Advance(time.Second)
+	_, err = mediator.Send(context.Background(), m, sendReport{}, tenant("acme"))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second}, clock.Waited(), "the token of the cancelled report should be given back")
+}
+
+func TestRateLimit_RemovesFullBuckets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	limit := behavior.NewRateLimit(1, 2, behavior.WithLimitClock(clock), behavior.WithLimitKey(func(msg mediator.Message) string {
+		return msg.Metadata().Get(mediator.MetadataTenant)
+	})).(*behavior.RateLimit)
+	m := mediator.New(mediator.WithRequestBehaviors(limit))
+
+	for i := range 1000 {
+		_, err := mediator.Send(ctx, m, sendReport{}, tenant(fmt.Sprint(i)))
+		require.NoError(t, err)
+		clock.Advance(100 * time.Millisecond)
+	}
+	assert.Less(t, limit.Len(), 1000, "full buckets should be removed")
+
+	for range 2 {
+		_, err := mediator.Send(ctx, m, sendReport{}, tenant("999"))
+		require.NoError(t, err)
+	}
+	assert.Len(t, clock.Waited(), 1, "buckets that aren't full should be kept")
+}
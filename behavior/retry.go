@@ -0,0 +1,168 @@
+package behavior
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+// MetadataAttempt is the [mediator.Metadata] key that holds the attempt number of the [Retry] behavior.
+// The first attempt is 1.
+const MetadataAttempt = "attempt"
+
+type (
+	// NonIdempotent is implemented by requests that aren't safe to handle more than once.
+	// The [Retry] behavior never retries them.
+	NonIdempotent interface {
+		NonIdempotent()
+	}
+
+	// Clock is used by behaviors to wait, it can be replaced in tests.
+	Clock interface {
+		Now() time.Time
+		// After waits for the duration to elapse and then sends the current time on the returned channel.
+		After(d time.Duration) <-chan time.Time
+	}
+
+	realClock struct{}
+
+	// Retry is a [mediator.Behavior] that handles a message again when it fails with a retryable error.
+	//
+	// The behavior waits between attempts using exponential backoff with jitter.
+	// Waiting stops when the context is done.
+	// The attempt number is stored in the [mediator.Metadata] of the message with the [MetadataAttempt] key,
+	// so behaviors after the retry behavior, like [Slogger] and [OtelTracer], can report it.
+	Retry struct {
+		maxAttempts  int
+		initialDelay time.Duration
+		maxDelay     time.Duration
+		retryable    func(err error) bool
+		clock        Clock
+	}
+
+	// RetryOption defines the method to customize [NewRetry].
+	RetryOption  func(*retryOptions)
+	retryOptions struct {
+		maxAttempts  int
+		initialDelay time.Duration
+		maxDelay     time.Duration
+		retryable    func(err error) bool
+		clock        Clock
+	}
+)
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithMaxAttempts sets the maximum amount of times a message is handled, including the first attempt.
+// The default is 3.
+func WithMaxAttempts(attempts int) RetryOption {
+	return func(o *retryOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the maximum delay between attempts.
+// The delay doubles after every attempt, until it reaches the maximum.
+// The default is 100 milliseconds with a maximum of 5 seconds.
+func WithBackoff(initial, maximum time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.initialDelay = initial
+		o.maxDelay = maximum
+	}
+}
+
+// WithRetryable sets the function that decides if an error is worth retrying.
+// By default, every error is retried, except for the errors of a cancelled context.
+func WithRetryable(retryable func(err error) bool) RetryOption {
+	return func(o *retryOptions) {
+		o.retryable = retryable
+	}
+}
+
+// WithClock replaces the [Clock] that is used to wait, this is useful in tests.
+func WithClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.clock = clock
+	}
+}
+
+// Handler runs the [Retry] behavior.
+func (b *Retry) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		if _, ok := msg.GetInner().(NonIdempotent); ok {
+			return next.Handle(ctx, l, msg)
+		}
+
+		md := msg.Metadata()
+		for attempt := 1; ; attempt++ {
+			if md != nil {
+				md.Set(MetadataAttempt, strconv.Itoa(attempt))
+			}
+			resp, err := next.Handle(ctx, l, msg)
+			if err == nil || attempt >= b.maxAttempts || !b.retryable(err) {
+				return resp, err
+			}
+
+			delay := b.delay(attempt)
+			l.DebugContext(ctx, "retrying "+msg.String(), slog.Int(MetadataAttempt, attempt), slog.Duration("delay", delay), slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+				return resp, err
+			case <-b.clock.After(delay):
+			}
+		}
+	})
+}
+
+// delay returns the time to wait after the attempt.
+// It uses "equal jitter", so the delay is between half and the whole exponential backoff.
+func (b *Retry) delay(attempt int) time.Duration {
+	backoff := b.maxDelay
+	if shift := attempt - 1; shift < 32 && b.initialDelay<<shift < b.maxDelay && b.initialDelay<<shift > 0 {
+		backoff = b.initialDelay << shift
+	}
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + rand.N(half+1)
+}
+
+// notCancelled reports if the error isn't caused by a cancelled context.
+// It is the default classifier of the behaviors that handle errors.
+func notCancelled(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// NewRetry creates a new [Retry] [mediator.Behavior].
+func NewRetry(opt ...RetryOption) mediator.Behavior {
+	// default options
+	opts := &retryOptions{
+		maxAttempts:  3,
+		initialDelay: 100 * time.Millisecond,
+		maxDelay:     5 * time.Second,
+		retryable:    notCancelled,
+		clock:        realClock{},
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Retry{
+		maxAttempts:  opts.maxAttempts,
+		initialDelay: opts.initialDelay,
+		maxDelay:     opts.maxDelay,
+		retryable:    opts.retryable,
+		clock:        opts.clock,
+	}
+}
@@ -0,0 +1,217 @@
+package behavior_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+type (
+	// fakeClock doesn't wait, it records the durations it was asked to wait for.
+	fakeClock struct {
+		mu     sync.Mutex
+		now    time.Time
+		waited []time.Duration
+		// block makes After never fire
+		block bool
+	}
+
+	// flakyQuery fails until it was handled the given amount of times.
+	flakyQuery struct {
+		failures int
+		err      error
+		calls    *int
+	}
+
+	// chargeCard is a command that can't be retried.
+	chargeCard struct {
+		calls *int
+	}
+)
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waited = append(c.waited, d)
+	ch := make(chan time.Time, 1)
+	if !c.block {
+		c.now = c.now.Add(d)
+		ch <- c.now
+	}
+	return ch
+}
+
+func (c *fakeClock) Waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waited...)
+}
+
+func (q flakyQuery) Handle(_ context.Context, _ *slog.Logger) (string, error) {
+	*q.calls++
+	if *q.calls <= q.failures {
+		return "", q.err
+	}
+	return "ok", nil
+}
+
+func (c chargeCard) Handle(_ context.Context, _ *slog.Logger) error {
+	*c.calls++
+	return errors.New("card declined")
+}
+
+func (chargeCard) NonIdempotent() {}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := &fakeClock{}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRetry(
+		behavior.WithMaxAttempts(4),
+		behavior.WithBackoff(100*time.Millisecond, 300*time.Millisecond),
+		behavior.WithClock(clock),
+	)))
+
+	calls := 0
+	resp, err := mediator.Send(ctx, m, flakyQuery{failures: 3, err: errors.New("connection reset"), calls: &calls})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, 4, calls)
+
+	waited := clock.Waited()
+	require.Len(t, waited, 3)
+	// the backoff doubles until it reaches the maximum, the jitter takes up to half of it
+	for i, backoff := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond} {
+		assert.GreaterOrEqual(t, waited[i], backoff/2)
+		assert.LessOrEqual(t, waited[i], backoff)
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRetry(
+		behavior.WithMaxAttempts(2),
+		behavior.WithClock(&fakeClock{}),
+	)))
+
+	calls := 0
+	reqErr := errors.New("connection reset")
+	_, err := mediator.Send(ctx, m, flakyQuery{failures: 5, err: reqErr, calls: &calls})
+	require.ErrorIs(t, err, reqErr)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetry_Retryable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errTransient := errors.New("deadlock detected")
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRetry(
+		behavior.WithRetryable(func(err error) bool {
+			return errors.Is(err, errTransient)
+		}),
+		behavior.WithClock(&fakeClock{}),
+	)))
+
+	calls := 0
+	_, err := mediator.Send(ctx, m, flakyQuery{failures: 1, err: errTransient, calls: &calls})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	errPermanent := errors.New("unique constraint violated")
+	_, err = mediator.Send(ctx, m, flakyQuery{failures: 1, err: errPermanent, calls: &calls})
+	require.ErrorIs(t, err, errPermanent)
+	assert.Equal(t, 1, calls, "errors that aren't retryable shouldn't be retried")
+}
+
+func TestRetry_NonIdempotent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRetry(behavior.WithClock(&fakeClock{}))))
+
+	calls := 0
+	err := mediator.Dispatch(ctx, m, chargeCard{calls: &calls})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetry_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &fakeClock{block: true}
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewRetry(behavior.WithClock(clock))))
+
+	calls := 0
+	reqErr := errors.New("connection reset")
+	done := make(chan error)
+	go func() {
+		_, err := mediator.Send(ctx, m, flakyQuery{failures: 5, err: reqErr, calls: &calls})
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(clock.Waited()) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, reqErr)
+	case <-time.After(time.Second):
+		t.Fatal("the retry should stop waiting when the context is done")
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetry_AttemptMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, nil))
+
+	m := mediator.New(
+		mediator.WithLogger(l),
+		mediator.WithRequestBehaviors(
+			behavior.NewRetry(behavior.WithClock(&fakeClock{})),
+			behavior.NewLogger(l),
+		),
+	)
+
+	calls := 0
+	_, err := mediator.Send(ctx, m, flakyQuery{failures: 2, err: errors.New("connection reset"), calls: &calls})
+	require.NoError(t, err)
+
+	logs := buf.String()
+	assert.Contains(t, logs, "attempt=1")
+	assert.Contains(t, logs, "attempt=2")
+	assert.Contains(t, logs, "attempt=3")
+}
@@ -0,0 +1,87 @@
+package behavior
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// Deduplicatable is implemented by requests that can share their response with concurrent requests
+	// that have the same key, see [Singleflight].
+	Deduplicatable interface {
+		// DedupeKey identifies the request, keys only have to be unique per request type.
+		DedupeKey() string
+	}
+
+	// Singleflight is a [mediator.Behavior] that handles concurrent messages with the same dedupe key once.
+	// Every caller gets the shared response, so the response shouldn't be modified.
+	//
+	// The key is taken from [Deduplicatable] messages, or from the function of [WithDedupeKey].
+	// Messages without a key and stream requests are handled as usual.
+	//
+	// A caller that cancels stops waiting without failing the others.
+	// The handler runs with a context that is cancelled when every caller stopped waiting,
+	// deadlines of the callers don't apply to it.
+	Singleflight struct {
+		key     func(msg mediator.Message) string
+		flights flightGroup
+	}
+
+	// SingleflightOption defines the method to customize [NewSingleflight].
+	SingleflightOption  func(*singleflightOptions)
+	singleflightOptions struct {
+		key func(msg mediator.Message) string
+	}
+)
+
+// WithDedupeKey sets the function that returns the dedupe key of a message.
+// An empty key means the message isn't deduplicated.
+// By default, the key of [Deduplicatable] messages is used.
+func WithDedupeKey(key func(msg mediator.Message) string) SingleflightOption {
+	return func(o *singleflightOptions) {
+		o.key = key
+	}
+}
+
+// Handler runs the [Singleflight] behavior.
+func (b *Singleflight) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		key := b.key(msg)
+		// the results of a stream are yielded to its caller only, so they can't be shared
+		if key == "" || mediator.IsStream(msg) {
+			return next.Handle(ctx, l, msg)
+		}
+
+		resp, shared, err := b.flights.do(ctx, typeScopedKey(msg, key), func(ctx context.Context) (any, error) {
+			return next.Handle(ctx, l, msg)
+		})
+		if shared {
+			l.DebugContext(ctx, "shared the response of "+msg.String(), slog.String("dedupe_key", key))
+		}
+		return resp, err
+	})
+}
+
+// dedupeKey returns the key of [Deduplicatable] messages.
+func dedupeKey(msg mediator.Message) string {
+	if d, ok := msg.GetInner().(Deduplicatable); ok {
+		return d.DedupeKey()
+	}
+	return ""
+}
+
+// NewSingleflight creates a new [Singleflight] [mediator.Behavior].
+func NewSingleflight(opt ...SingleflightOption) mediator.Behavior {
+	// default options
+	opts := &singleflightOptions{
+		key: dedupeKey,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Singleflight{
+		key: opts.key,
+	}
+}
@@ -0,0 +1,230 @@
+package behavior_test
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+// expensiveQuery blocks until it is released, or until its context is done.
+type expensiveQuery struct {
+	params    string
+	calls     *atomic.Int32
+	release   <-chan struct{}
+	cancelled chan<- struct{}
+}
+
+func (q expensiveQuery) Handle(ctx context.Context, _ *slog.Logger) (string, error) {
+	q.calls.Add(1)
+	select {
+	case <-q.release:
+		return "result of " + q.params, nil
+	case <-ctx.Done():
+		if q.cancelled != nil {
+			close(q.cancelled)
+		}
+		return "", ctx.Err()
+	}
+}
+
+func (q expensiveQuery) DedupeKey() string {
+	return q.params
+}
+
+// waitForCalls waits until the query was handled the given amount of times.
+func waitForCalls(t *testing.T, calls *atomic.Int32, n int32) {
+	t.Helper()
+	require.Eventually(t, func() bool { return calls.Load() == n }, time.Second, time.Millisecond)
+}
+
+func TestSingleflight(t *testing.T) {
+	t.Parallel()
+
+	b := behavior.NewSingleflight().(*behavior.Singleflight)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, params := range []string{"a", "a", "a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := mediator.Send(context.Background(), m, expensiveQuery{params: params, calls: calls, release: release})
+			assert.NoError(t, err)
+			assert.Equal(t, "result of "+params, resp)
+		}()
+	}
+	waitForWaiters(t, b, 4)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), calls.Load(), "only queries with different keys should be handled")
+}
+
+func TestSingleflight_CallerCancels(t *testing.T) {
+	t.Parallel()
+
+	b := behavior.NewSingleflight().(*behavior.Singleflight)
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := mediator.Send(ctx, m, expensiveQuery{params: "a", calls: calls, release: release})
+		first <- err
+	}()
+	waitForCalls(t, calls, 1)
+
+	second := make(chan string)
+	go func() {
+		resp, err := mediator.Send(context.Background(), m, expensiveQuery{params: "a", calls: calls, release: release})
+		assert.NoError(t, err)
+		second <- resp
+	}()
+	waitForWaiters(t, b, 2)
+
+	cancel()
+	require.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "result of a", <-second, "the other caller should still get the result")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestSingleflight_AllCallersCancel(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewSingleflight()))
+
+	calls := &atomic.Int32{}
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := mediator.Send(ctx, m, expensiveQuery{params: "a", calls: calls, release: make(chan struct{}), cancelled: cancelled})
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		require.Fail(t, "the handler should be cancelled when nobody waits for it")
+	}
+}
+
+func TestSingleflight_Key(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewSingleflight(
+		behavior.WithDedupeKey(func(mediator.Message) string { return "" }),
+	)))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	close(release)
+	for range 2 {
+		_, err := mediator.Send(ctx, m, expensiveQuery{params: "a", calls: calls, release: release})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load(), "messages without a key should be handled as usual")
+}
+
+type (
+	expensiveUserQuery  struct{ expensiveQuery }
+	expensiveOrderQuery struct{ expensiveQuery }
+)
+
+func (q *expensiveUserQuery) Handle(ctx context.Context, l *slog.Logger) (string, error) {
+	resp, err := q.expensiveQuery.Handle(ctx, l)
+	return "user " + resp, err
+}
+
+func (q *expensiveOrderQuery) Handle(ctx context.Context, l *slog.Logger) (string, error) {
+	resp, err := q.expensiveQuery.Handle(ctx, l)
+	return "order " + resp, err
+}
+
+func TestSingleflight_PointerRequests(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewSingleflight()))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	user := make(chan string)
+	go func() {
+		resp, err := mediator.Send(context.Background(), m, &expensiveUserQuery{expensiveQuery{params: "a", calls: calls, release: release}})
+		assert.NoError(t, err)
+		user <- resp
+	}()
+	order := make(chan string)
+	go func() {
+		resp, err := mediator.Send(context.Background(), m, &expensiveOrderQuery{expensiveQuery{params: "a", calls: calls, release: release}})
+		assert.NoError(t, err)
+		order <- resp
+	}()
+	waitForCalls(t, calls, 2)
+	close(release)
+
+	assert.Equal(t, "user result of a", <-user)
+	assert.Equal(t, "order result of a", <-order, "requests of different types shouldn't share a flight")
+}
+
+// expensiveStream blocks until it is released, and then streams its results.
+type expensiveStream struct {
+	calls   *atomic.Int32
+	release <-chan struct{}
+}
+
+func (q expensiveStream) Handle(_ context.Context, _ *slog.Logger) iter.Seq2[string, error] {
+	q.calls.Add(1)
+	return func(yield func(string, error) bool) {
+		<-q.release
+		for _, name := range []string{"Gus", "Gert"} {
+			if !yield(name, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (q expensiveStream) DedupeKey() string {
+	return "all"
+}
+
+func TestSingleflight_Stream(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithRequestBehaviors(behavior.NewSingleflight()))
+
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var names []string
+			for name, err := range mediator.SendStream(context.Background(), m, expensiveStream{calls: calls, release: release}) {
+				assert.NoError(t, err)
+				names = append(names, name)
+			}
+			assert.Equal(t, []string{"Gus", "Gert"}, names, "every caller should get the results of its stream")
+		}()
+	}
+	waitForCalls(t, calls, 2)
+	close(release)
+	wg.Wait()
+}
@@ -15,6 +15,9 @@ import (
 // that is passed through it.
 // It also logs the request after it is handled.
 // This includes the time it took to handle the request and the error if it is not nil.
+// The message and correlation id from the [mediator.Metadata] are added to the logger as well,
+// so all logs of one flow can be found. The attempt number of the [Retry] behavior is logged when it is set,
+// whether the retry behavior runs before or after the logger behavior.
 type Slogger struct {
 	l *slog.Logger
 }
@@ -23,13 +26,28 @@ type Slogger struct {
 func (b Slogger) Handler(next mediator.Handler) mediator.Handler {
 	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
 		l = l.With(msg.Type().String(), msg.String())
+		md := msg.Metadata()
+		if len(md) > 0 {
+			l = l.With(
+				slog.String(mediator.MetadataMessageID, md.MessageID()),
+				slog.String(mediator.MetadataCorrelationID, md.CorrelationID()),
+			)
+		}
+		nextLogger := l
+		if attempt := md.Get(MetadataAttempt); attempt != "" {
+			nextLogger = l.With(slog.String(MetadataAttempt, attempt))
+		}
 
 		start := time.Now()
-		resp, err := next.Handle(ctx, l, msg)
+		resp, err := next.Handle(ctx, nextLogger, msg)
 
 		logArgs := []any{
 			slog.Duration("elapsed", time.Since(start)),
 		}
+		// the attempt is read after handling, so the last attempt is logged when the retry behavior runs after this one
+		if attempt := md.Get(MetadataAttempt); attempt != "" {
+			logArgs = append(logArgs, slog.String(MetadataAttempt, attempt))
+		}
 
 		if err != nil {
 			logArgs = append(logArgs, slog.Any("error", err))
@@ -18,6 +18,7 @@ import (
 
 type fakeRequest struct {
 	handleFunc func(_ context.Context, _ mediator.Message) (any, error)
+	metadata   mediator.Metadata
 }
 
 func (n fakeRequest) GetInner() any {
@@ -28,6 +29,10 @@ func (n fakeRequest) String() string {
 	return reflect.TypeOf(n).Name()
 }
 
+func (n fakeRequest) Metadata() mediator.Metadata {
+	return n.metadata
+}
+
 func (n fakeRequest) Type() mediator.MessageType {
 	return mediator.TypeRequest
 }
@@ -98,3 +103,60 @@ func TestLogger_Handler_Error(t *testing.T) {
 	assert.Equal(t, "an error occurred while processing fakeRequest", m["msg"])
 	assert.NotEmpty(t, m["elapsed"])
 }
+
+func TestLogger_Handler_Metadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := fakeRequest{metadata: mediator.Metadata{
+		mediator.MetadataMessageID:     "message-1",
+		mediator.MetadataCorrelationID: "flow-1",
+	}}
+
+	behav := behavior.NewLogger(l)
+	_, err := behav.Handler(handler).Handle(ctx, l, handler)
+	require.NoError(t, err)
+
+	var m map[string]any
+	err = json.Unmarshal(bytes.Split(buf.Bytes(), []byte{'\n'})[0], &m)
+	require.NoError(t, err)
+
+	assert.Equal(t, "message-1", m[mediator.MetadataMessageID])
+	assert.Equal(t, "flow-1", m[mediator.MetadataCorrelationID])
+}
+
+func TestLogger_Handler_Attempt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cases := map[string]func(l *slog.Logger) mediator.Option{
+		"retry before logger": func(l *slog.Logger) mediator.Option {
+			return mediator.WithRequestBehaviors(behavior.NewRetry(behavior.WithBackoff(0, 0)), behavior.NewLogger(l))
+		},
+		"retry after logger": func(l *slog.Logger) mediator.Option {
+			return mediator.WithRequestBehaviors(behavior.NewLogger(l), behavior.NewRetry(behavior.WithBackoff(0, 0)))
+		},
+	}
+	for name, behaviors := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			l := slog.New(slog.NewJSONHandler(&buf, nil))
+			m := mediator.New(behaviors(l), mediator.WithLogger(l))
+
+			calls := 0
+			_, err := mediator.Send(ctx, m, flakyQuery{failures: 1, err: errors.New("connection reset"), calls: &calls})
+			require.NoError(t, err)
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte{'\n'})
+			var last map[string]any
+			require.NoError(t, json.Unmarshal(lines[len(lines)-1], &last))
+			assert.Equal(t, "processed flakyQuery", last["msg"])
+			assert.Equal(t, "2", last[behavior.MetadataAttempt])
+		})
+	}
+}
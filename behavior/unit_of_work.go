@@ -0,0 +1,88 @@
+package behavior
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// TxManager manages the transactions of the [UnitOfWork] behavior.
+	TxManager interface {
+		// Begin starts a transaction and returns a context that holds it,
+		// the message is handled with the returned context.
+		Begin(ctx context.Context) (context.Context, error)
+		// Commit commits the transaction in the context.
+		Commit(ctx context.Context) error
+		// Rollback rolls back the transaction in the context.
+		Rollback(ctx context.Context) error
+	}
+
+	// UnitOfWork is a [mediator.Behavior] that handles a message in a transaction of the [TxManager].
+	// It is meant for the command [mediator.Pipeline].
+	//
+	// The notifications that are published while the message is handled are queued in a [mediator.PublishBuffer].
+	// They are only published after the transaction is committed, and dropped when it is rolled back.
+	// The transaction is rolled back when the message fails or panics.
+	// Errors of the handlers of the queued notifications are logged, they don't fail the committed message.
+	//
+	// Messages that are sent while a unit of work is in progress join it, they don't start a new transaction.
+	UnitOfWork struct {
+		tx TxManager
+	}
+)
+
+// Handler runs the [UnitOfWork] behavior.
+func (b *UnitOfWork) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (resp any, err error) {
+		if mediator.PublishBufferFromContext(ctx) != nil {
+			return next.Handle(ctx, l, msg)
+		}
+
+		ctx, buffer := mediator.ContextWithPublishBuffer(ctx)
+		txCtx, err := b.tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin the transaction of %s: %w", msg.String(), err)
+		}
+
+		finished := false
+		defer func() {
+			if finished {
+				return
+			}
+			buffer.Discard()
+			// the transaction has to be rolled back, even if the message was cancelled
+			if rbErr := b.tx.Rollback(context.WithoutCancel(txCtx)); rbErr != nil {
+				rbErr = fmt.Errorf("failed to roll back the transaction of %s: %w", msg.String(), rbErr)
+				l.ErrorContext(ctx, rbErr.Error())
+				err = errors.Join(err, rbErr)
+			}
+		}()
+
+		resp, err = next.Handle(txCtx, l, msg)
+		if err != nil {
+			return resp, err
+		}
+		// a failed commit ends the transaction as well, so it isn't rolled back
+		finished = true
+		if err := b.tx.Commit(txCtx); err != nil {
+			buffer.Discard()
+			return nil, fmt.Errorf("failed to commit the transaction of %s: %w", msg.String(), err)
+		}
+
+		if err := buffer.Flush(ctx); err != nil {
+			l.ErrorContext(ctx, "failed to publish the notifications of "+msg.String(), slog.Any("error", err))
+		}
+		return resp, nil
+	})
+}
+
+// NewUnitOfWork creates a new [UnitOfWork] [mediator.Behavior] that uses the [TxManager] for its transactions.
+func NewUnitOfWork(tx TxManager) mediator.Behavior {
+	return &UnitOfWork{
+		tx: tx,
+	}
+}
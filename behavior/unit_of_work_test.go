@@ -0,0 +1,135 @@
+package behavior_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+type (
+	// fakeTxManager records the calls to the transactions it manages.
+	fakeTxManager struct {
+		calls     []string
+		commitErr error
+	}
+
+	txKey struct{}
+
+	// registerGopher publishes gopherRegistered and fails when err is set.
+	registerGopher struct {
+		name   string
+		err    error
+		nested bool
+	}
+
+	gopherRegistered struct {
+		name string
+	}
+)
+
+func (f *fakeTxManager) Begin(ctx context.Context) (context.Context, error) {
+	f.calls = append(f.calls, "begin")
+	return context.WithValue(ctx, txKey{}, len(f.calls)), nil
+}
+
+func (f *fakeTxManager) Commit(ctx context.Context) error {
+	if ctx.Value(txKey{}) == nil {
+		return errors.New("no transaction")
+	}
+	f.calls = append(f.calls, "commit")
+	return f.commitErr
+}
+
+func (f *fakeTxManager) Rollback(ctx context.Context) error {
+	if ctx.Value(txKey{}) == nil {
+		return errors.New("no transaction")
+	}
+	f.calls = append(f.calls, "rollback")
+	return nil
+}
+
+func (c registerGopher) Handle(ctx context.Context, _ *slog.Logger) error {
+	m := mediator.SenderFromContext(ctx).(mediator.Mediator)
+	if err := mediator.Publish(ctx, m, gopherRegistered{name: c.name}); err != nil {
+		return err
+	}
+	if c.nested {
+		if err := mediator.Dispatch(ctx, m, registerGopher{name: c.name + " jr."}); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+func newUnitOfWorkMediator(t *testing.T, tx *fakeTxManager) (mediator.Mediator, *[]string) {
+	t.Helper()
+	m := mediator.New(mediator.WithCommandBehaviors(behavior.NewUnitOfWork(tx)))
+	var registered []string
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, e gopherRegistered) error {
+		tx.calls = append(tx.calls, "published "+e.name)
+		registered = append(registered, e.name)
+		return nil
+	}))
+	require.NoError(t, err)
+	return m, &registered
+}
+
+func TestUnitOfWork(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTxManager{}
+	m, registered := newUnitOfWorkMediator(t, tx)
+
+	require.NoError(t, mediator.Dispatch(context.Background(), m, registerGopher{name: "Gus", nested: true}))
+	assert.Equal(t, []string{"Gus", "Gus jr."}, *registered)
+	assert.Equal(t, []string{"begin", "commit", "published Gus", "published Gus jr."}, tx.calls,
+		"nested commands should join the unit of work and notifications should be published after the commit")
+}
+
+func TestUnitOfWork_Rollback(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTxManager{}
+	m, registered := newUnitOfWorkMediator(t, tx)
+
+	cmdErr := errors.New("gopher already exists")
+	err := mediator.Dispatch(context.Background(), m, registerGopher{name: "Gus", err: cmdErr})
+	require.ErrorIs(t, err, cmdErr)
+	assert.Empty(t, *registered, "notifications of a rolled back unit of work shouldn't be published")
+	assert.Equal(t, []string{"begin", "rollback"}, tx.calls)
+}
+
+func TestUnitOfWork_CommitFails(t *testing.T) {
+	t.Parallel()
+
+	errCommit := errors.New("serialization failure")
+	tx := &fakeTxManager{commitErr: errCommit}
+	m, registered := newUnitOfWorkMediator(t, tx)
+
+	err := mediator.Dispatch(context.Background(), m, registerGopher{name: "Gus"})
+	require.ErrorIs(t, err, errCommit)
+	assert.Empty(t, *registered)
+	assert.Equal(t, []string{"begin", "commit"}, tx.calls)
+}
+
+func TestUnitOfWork_Panic(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTxManager{}
+	behav := behavior.NewUnitOfWork(tx)
+	handler := behav.Handler(mediator.HandlerFunc(func(context.Context, *slog.Logger, mediator.Message) (any, error) {
+		panic("gopher panicked")
+	}))
+
+	assert.PanicsWithValue(t, "gopher panicked", func() {
+		_, _ = handler.Handle(context.Background(), slog.Default(), mediator.NewCommandMessage(registerGopher{}))
+	})
+	assert.Equal(t, []string{"begin", "rollback"}, tx.calls)
+}
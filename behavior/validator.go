@@ -0,0 +1,372 @@
+package behavior
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// Validatable is implemented by requests and notifications that can validate themselves.
+	// The [Validator] behavior calls Validate before the message is handled.
+	Validatable interface {
+		Validate(ctx context.Context) error
+	}
+
+	// Validator is a [mediator.Behavior] that validates requests and notifications before they are handled.
+	//
+	// A message is validated when it implements [Validatable], when a validator is registered for its type
+	// with [WithTypeValidator], and, if enabled with [WithStructTags], using its `validate` struct tags.
+	// All failures are collected in a [ValidationError].
+	Validator struct {
+		validators []typeValidator
+		structTags bool
+		// rules caches the struct tag rules per type
+		rules sync.Map
+	}
+
+	// ValidatorOption defines the method to customize [NewValidator].
+	ValidatorOption  func(*validatorOptions)
+	validatorOptions struct {
+		validators []typeValidator
+		structTags bool
+	}
+
+	typeValidator struct {
+		t        reflect.Type
+		validate func(ctx context.Context, v any) error
+	}
+
+	// ValidationError is returned by the [Validator] behavior when a message is invalid.
+	// It lists every field that failed, so it can be rendered as a response directly.
+	ValidationError struct {
+		// Message is the name of the message that failed validation.
+		Message string
+		Fields  []FieldError
+		// causes are the errors of validators that didn't return a ValidationError
+		causes []error
+	}
+
+	// FieldError is a single validation failure.
+	FieldError struct {
+		// Field is the path of the field, like `address.street` or `items[2].name`.
+		// The name from the json tag is used if the field has one.
+		// It is empty if the failure is about the message as a whole.
+		Field string `json:"field"`
+		// Reason describes why the field is invalid.
+		Reason string `json:"reason"`
+	}
+
+	// fieldRules are the validation rules of a struct field.
+	fieldRules struct {
+		index []int
+		name  string
+		rules []rule
+		// nested is true if the field should be validated recursively
+		nested bool
+	}
+
+	rule struct {
+		name  string
+		param string
+	}
+
+	// visitedPointer identifies a pointer that is being validated, the type is part of it,
+	// because a struct and its first field have the same address.
+	visitedPointer struct {
+		ptr uintptr
+		t   reflect.Type
+	}
+)
+
+// WithTypeValidator registers a validation function for messages of type T.
+// T can be an interface, the function is then used for every message that implements it.
+//
+// The function can return a [ValidationError] to report failures of specific fields,
+// other errors are reported as a failure of the whole message.
+func WithTypeValidator[T any](validate func(ctx context.Context, v T) error) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.validators = append(o.validators, typeValidator{
+			t: reflect.TypeFor[T](),
+			validate: func(ctx context.Context, v any) error {
+				return validate(ctx, v.(T))
+			},
+		})
+	}
+}
+
+// WithStructTags enables validation using `validate` struct tags.
+// The tag holds a comma separated list of rules:
+//
+//   - required: the field can't be the zero value.
+//   - min=n: the length of a string, slice or map, or the value of a number is at least n.
+//   - max=n: the length of a string, slice or map, or the value of a number is at most n.
+//   - email: the field is an email address, empty strings are only rejected with required.
+//
+// Nested structs, pointers to structs and slices of structs are validated as well.
+//
+//	type CreateUser struct {
+//		Name  string `json:"name" validate:"required,min=1,max=64"`
+//		Email string `json:"email" validate:"required,email"`
+//	}
+func WithStructTags() ValidatorOption {
+	return func(o *validatorOptions) {
+		o.structTags = true
+	}
+}
+
+// Handler runs the [Validator] behavior.
+func (v *Validator) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		if err := v.validate(ctx, msg); err != nil {
+			return nil, err
+		}
+		return next.Handle(ctx, l, msg)
+	})
+}
+
+func (v *Validator) validate(ctx context.Context, msg mediator.Message) error {
+	inner := msg.GetInner()
+	if inner == nil {
+		return nil
+	}
+	vErr := &ValidationError{Message: msg.String()}
+
+	if val, ok := inner.(Validatable); ok {
+		vErr.add(val.Validate(ctx))
+	}
+	t := reflect.TypeOf(inner)
+	for _, tv := range v.validators {
+		if t == tv.t || (tv.t.Kind() == reflect.Interface && t.Implements(tv.t)) {
+			vErr.add(tv.validate(ctx, inner))
+		}
+	}
+	if v.structTags {
+		v.validateStruct(reflect.ValueOf(inner), "", vErr, make(map[visitedPointer]struct{}))
+	}
+
+	if len(vErr.Fields) == 0 {
+		return nil
+	}
+	return *vErr
+}
+
+// add adds the failures of the error to the validation error.
+func (e *ValidationError) add(err error) {
+	if err == nil {
+		return
+	}
+	var vErr ValidationError
+	if errors.As(err, &vErr) {
+		e.Fields = append(e.Fields, vErr.Fields...)
+		e.causes = append(e.causes, vErr.causes...)
+		return
+	}
+	e.Fields = append(e.Fields, FieldError{Reason: err.Error()})
+	e.causes = append(e.causes, err)
+}
+
+func (e ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		reasons = append(reasons, f.String())
+	}
+	return fmt.Sprintf("validation of %s failed: %s", e.Message, strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the errors of validators that didn't return a [ValidationError].
+func (e ValidationError) Unwrap() []error {
+	return e.causes
+}
+
+func (f FieldError) String() string {
+	if f.Field == "" {
+		return f.Reason
+	}
+	return fmt.Sprintf("%s %s", f.Field, f.Reason)
+}
+
+// validateStruct validates the struct tags of the value and its nested structs.
+// The pointers that are being validated are kept in visiting, so a struct that points back to itself isn't validated again.
+func (v *Validator) validateStruct(val reflect.Value, path string, vErr *ValidationError, visiting map[visitedPointer]struct{}) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		if val.Kind() == reflect.Pointer {
+			p := visitedPointer{ptr: val.Pointer(), t: val.Type()}
+			if _, ok := visiting[p]; ok {
+				return
+			}
+			visiting[p] = struct{}{}
+			defer delete(visiting, p)
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, fr := range v.structRules(val.Type()) {
+		field := val.FieldByIndex(fr.index)
+		fieldPath := fr.name
+		if path != "" {
+			fieldPath = path + "." + fr.name
+		}
+		for _, r := range fr.rules {
+			// only report the first failing rule of a field
+			if reason := r.check(field); reason != "" {
+				vErr.Fields = append(vErr.Fields, FieldError{Field: fieldPath, Reason: reason})
+				break
+			}
+		}
+		if !fr.nested {
+			continue
+		}
+		if field.Kind() == reflect.Slice || field.Kind() == reflect.Array {
+			for i := range field.Len() {
+				v.validateStruct(field.Index(i), fmt.Sprintf("%s[%d]", fieldPath, i), vErr, visiting)
+			}
+		} else {
+			v.validateStruct(field, fieldPath, vErr, visiting)
+		}
+	}
+}
+
+// structRules returns the rules of the fields of a struct type.
+func (v *Validator) structRules(t reflect.Type) []fieldRules {
+	if cached, ok := v.rules.Load(t); ok {
+		return cached.([]fieldRules)
+	}
+
+	var fields []fieldRules
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fr := fieldRules{
+			index:  f.Index,
+			name:   fieldName(f),
+			rules:  parseRules(f.Tag.Get("validate")),
+			nested: hasStruct(f.Type),
+		}
+		if len(fr.rules) > 0 || fr.nested {
+			fields = append(fields, fr)
+		}
+	}
+	v.rules.Store(t, fields)
+	return fields
+}
+
+// fieldName returns the name of the field in the json tag, or the name of the field itself.
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// hasStruct reports if values of the type can hold structs that should be validated.
+func hasStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func parseRules(tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []rule
+	for _, r := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(r), "=")
+		if name != "" {
+			rules = append(rules, rule{name: name, param: param})
+		}
+	}
+	return rules
+}
+
+// check returns the reason the value breaks the rule, or an empty string if it doesn't.
+func (r rule) check(val reflect.Value) string {
+	switch r.name {
+	case "required":
+		if val.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		n, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			return fmt.Sprintf("has an invalid %s rule %q", r.name, r.param)
+		}
+		size, isLength, ok := measure(val)
+		if !ok || (r.name == "min" && size >= n) || (r.name == "max" && size <= n) {
+			return ""
+		}
+		bound := "at least"
+		if r.name == "max" {
+			bound = "at most"
+		}
+		if isLength {
+			return fmt.Sprintf("must have a length of %s %s", bound, r.param)
+		}
+		return fmt.Sprintf("must be %s %s", bound, r.param)
+	case "email":
+		if val.Kind() != reflect.String || val.String() == "" {
+			return ""
+		}
+		if addr, err := mail.ParseAddress(val.String()); err != nil || addr.Address != val.String() {
+			return "must be a valid email address"
+		}
+	default:
+		return fmt.Sprintf("has an unknown validation rule %q", r.name)
+	}
+	return ""
+}
+
+// measure returns the length of strings, slices and maps, or the value of numbers.
+func measure(val reflect.Value) (size float64, isLength bool, ok bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return float64(len([]rune(val.String()))), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(val.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(val.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false, true
+	case reflect.Pointer:
+		if val.IsNil() {
+			return 0, false, false
+		}
+		return measure(val.Elem())
+	default:
+		return 0, false, false
+	}
+}
+
+// NewValidator creates a new [Validator] [mediator.Behavior].
+// It can be added to the request and the notification [mediator.Pipeline].
+func NewValidator(opt ...ValidatorOption) mediator.Behavior {
+	opts := &validatorOptions{}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Validator{
+		validators: opts.validators,
+		structTags: opts.structTags,
+	}
+}
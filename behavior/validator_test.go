@@ -0,0 +1,213 @@
+package behavior_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/behavior"
+)
+
+type (
+	address struct {
+		Street string `json:"street" validate:"required"`
+	}
+	createUser struct {
+		Name      string    `json:"name" validate:"required,min=2,max=8"`
+		Email     string    `json:"email" validate:"email"`
+		Age       int       `validate:"min=18"`
+		Address   *address  `json:"address"`
+		Addresses []address `json:"addresses"`
+	}
+	user struct {
+		Name string
+	}
+	// employee points to other employees, which can point back to it
+	employee struct {
+		Name    string    `validate:"required"`
+		Manager *employee `json:"manager"`
+		Reports []*employee
+	}
+	renameUser struct {
+		name string
+	}
+	userRenamed struct {
+		name string
+	}
+)
+
+func (c renameUser) Validate(_ context.Context) error {
+	if c.name == "" {
+		return behavior.ValidationError{Fields: []behavior.FieldError{{Field: "name", Reason: "is required"}}}
+	}
+	return nil
+}
+
+func (c renameUser) Handle(_ context.Context, _ *slog.Logger) error {
+	return nil
+}
+
+func (e userRenamed) Validate(_ context.Context) error {
+	if e.name == "" {
+		return errors.New("a renamed user needs a name")
+	}
+	return nil
+}
+
+func newValidatedMediator(t *testing.T, opt ...behavior.ValidatorOption) mediator.Mediator {
+	t.Helper()
+	validator := behavior.NewValidator(opt...)
+	m := mediator.New(
+		mediator.WithRequestBehaviors(validator),
+		mediator.WithNotificationBehaviors(validator),
+	)
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req createUser) (user, error) {
+		return user{Name: req.Name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+	return m
+}
+
+func TestValidator_Validatable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := newValidatedMediator(t)
+
+	err := mediator.Dispatch(ctx, m, renameUser{})
+	var vErr behavior.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []behavior.FieldError{{Field: "name", Reason: "is required"}}, vErr.Fields)
+	assert.Equal(t, "validation of renameUser failed: name is required", err.Error())
+
+	require.NoError(t, mediator.Dispatch(ctx, m, renameUser{name: "Gus"}))
+}
+
+func TestValidator_Notification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := newValidatedMediator(t)
+
+	called := false
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ userRenamed) error {
+		called = true
+		return nil
+	}))
+	require.NoError(t, err)
+
+	err = mediator.Publish(ctx, m, userRenamed{})
+	var vErr behavior.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []behavior.FieldError{{Reason: "a renamed user needs a name"}}, vErr.Fields)
+	assert.False(t, called, "an invalid notification shouldn't be handled")
+}
+
+func TestValidator_TypeValidator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errTaken := errors.New("name is taken")
+	m := newValidatedMediator(t,
+		behavior.WithTypeValidator(func(_ context.Context, req createUser) error {
+			if req.Name == "Gus" {
+				return errTaken
+			}
+			return nil
+		}),
+		// validators can also be registered for an interface
+		behavior.WithTypeValidator(func(_ context.Context, _ behavior.Validatable) error {
+			return errors.New("should only be called for validatable messages")
+		}),
+	)
+
+	_, err := mediator.SendRequest[user](ctx, m, createUser{Name: "Gus"})
+	require.ErrorIs(t, err, errTaken, "the errors of validators should be unwrappable")
+
+	_, err = mediator.SendRequest[user](ctx, m, createUser{Name: "Gert"})
+	require.NoError(t, err)
+
+	err = mediator.Dispatch(ctx, m, renameUser{name: "Gert"})
+	require.ErrorContains(t, err, "should only be called for validatable messages")
+}
+
+func TestValidator_StructTags(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := newValidatedMediator(t, behavior.WithStructTags())
+
+	_, err := mediator.SendRequest[user](ctx, m, createUser{
+		Name:      "Gus",
+		Email:     "gus@example.com",
+		Age:       30,
+		Address:   &address{Street: "Gopher Lane"},
+		Addresses: []address{{Street: "Gopher Lane"}},
+	})
+	require.NoError(t, err)
+
+	_, err = mediator.SendRequest[user](ctx, m, createUser{
+		Name:      "G",
+		Email:     "not an email",
+		Age:       3,
+		Address:   &address{},
+		Addresses: []address{{Street: "Gopher Lane"}, {}},
+	})
+	var vErr behavior.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, "createUser", vErr.Message)
+	assert.Equal(t, []behavior.FieldError{
+		{Field: "name", Reason: "must have a length of at least 2"},
+		{Field: "email", Reason: "must be a valid email address"},
+		{Field: "Age", Reason: "must be at least 18"},
+		{Field: "address.street", Reason: "is required"},
+		{Field: "addresses[1].street", Reason: "is required"},
+	}, vErr.Fields)
+
+	_, err = mediator.SendRequest[user](ctx, m, createUser{Name: "Gustavius", Age: 18})
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []behavior.FieldError{
+		{Field: "name", Reason: "must have a length of at most 8"},
+	}, vErr.Fields, "an empty email and a nil address shouldn't be validated")
+
+	_, err = mediator.SendRequest[user](ctx, m, createUser{Age: 18})
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []behavior.FieldError{{Field: "name", Reason: "is required"}}, vErr.Fields)
+}
+
+func TestValidator_StructTagsCycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	validator := behavior.NewValidator(behavior.WithStructTags())
+	m := mediator.New(mediator.WithRequestBehaviors(validator))
+	require.NoError(t, mediator.RegisterHandler(m, mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req *employee) (string, error) {
+		return req.Name, nil
+	})))
+
+	boss := &employee{Name: "Gus"}
+	intern := &employee{Manager: boss}
+	boss.Manager = boss
+	boss.Reports = []*employee{intern}
+
+	_, err := mediator.SendRequest[string](ctx, m, boss)
+	var vErr behavior.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []behavior.FieldError{{Field: "Reports[0].Name", Reason: "is required"}}, vErr.Fields,
+		"structs that point back to themselves should only be validated once")
+}
+
+func TestValidator_StructTagsDisabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := newValidatedMediator(t)
+
+	_, err := mediator.SendRequest[user](ctx, m, createUser{})
+	require.NoError(t, err, "struct tags should only be validated when enabled")
+}
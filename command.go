@@ -0,0 +1,55 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+type (
+	// Command is a request that changes state and only succeeds or fails.
+	// Unlike [Request], it doesn't have a response value.
+	// It can be sent through the [Mediator] using [Dispatch].
+	Command interface {
+		// Handle executes the [Command].
+		// The [context.Context] parameter and the returned error can be accessed and altered in the [Pipeline].
+		Handle(ctx context.Context, l *slog.Logger) error
+	}
+
+	// ErrReadOnly is returned by [Dispatch] when a command is dispatched while a query is handled,
+	// and the [Mediator] is created with [WithReadOnlyQueries].
+	ErrReadOnly struct {
+		// Command is the name of the command that was rejected.
+		Command string
+		// Query is the name of the query that dispatched the command.
+		Query string
+	}
+)
+
+func (e ErrReadOnly) Error() string {
+	return fmt.Sprintf("command %s can't be dispatched while handling query %s", e.Command, e.Query)
+}
+
+// Dispatch sends a [Command] using a [Sender].
+//
+// The command passes through the request [Pipeline] as a [CommandMessage] with the [TypeCommand] type,
+// so behaviors can tell commands apart from other requests.
+// The command [Pipeline] consists of the request behaviors followed by the behaviors set with [WithCommandBehaviors].
+// The response of the behaviors is ignored.
+// The [RequestBehavior] instances of the command type, registered with struct{} as response type, run last.
+//
+// If the context belongs to a query that is handled by a [Mediator] with [WithReadOnlyQueries],
+// the command isn't handled and an [ErrReadOnly] error is returned.
+//
+// The [Sender] interface is implemented by [Mediator].
+func Dispatch(ctx context.Context, m Sender, cmd Command, options ...SendOption) error {
+	msg := commandMessage{cmd: cmd, typed: commandChainFor(m, cmd)}
+	if query := scopeFromContext(ctx).readOnly; query != "" {
+		return ErrReadOnly{Command: msg.String(), Query: query}
+	}
+
+	opts := newSendOptions(options)
+	msg.metadata = newMetadata(ctx, opts.metadata)
+	_, err := opts.handle(ctx, m, m.getCommandPipeline(), msg)
+	return err
+}
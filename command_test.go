@@ -0,0 +1,90 @@
+package mediator_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+type renameGopher struct {
+	gopher Gopher
+	name   string
+	err    error
+}
+
+func (c *renameGopher) Handle(_ context.Context, _ *slog.Logger) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.gopher.Name = c.name
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var types []mediator.MessageType
+	behav := &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+		types = append(types, msg.Type())
+		return next.Handle(ctx, l, msg)
+	}}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	cmd := &renameGopher{name: "Gus"}
+	require.NoError(t, mediator.Dispatch(ctx, m, cmd))
+	assert.Equal(t, "Gus", cmd.gopher.Name)
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []mediator.MessageType{mediator.TypeCommand, mediator.TypeRequest}, types)
+}
+
+func TestDispatch_Error(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	cmdErr := errors.New("gopher is hibernating")
+	err := mediator.Dispatch(ctx, m, &renameGopher{err: cmdErr})
+	require.ErrorIs(t, err, cmdErr)
+}
+
+func TestDispatch_BehaviorError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	behavErr := errors.New("not allowed")
+	m := mediator.New(mediator.WithRequestBehaviors(shortCircuit(nil, behavErr)))
+
+	cmd := &renameGopher{name: "Gus"}
+	err := mediator.Dispatch(ctx, m, cmd)
+	require.ErrorIs(t, err, behavErr)
+	assert.Empty(t, cmd.gopher.Name, "the command shouldn't be handled")
+}
+
+func TestDispatch_Fake(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var dispatched mediator.Message
+	m := mediator.NewFake(func(_ context.Context, _ *slog.Logger, msg mediator.Message) (any, error) {
+		dispatched = msg
+		return nil, nil
+	})
+
+	cmd := &renameGopher{name: "Gus"}
+	require.NoError(t, mediator.Dispatch(ctx, m, cmd))
+	require.NotNil(t, dispatched)
+	assert.Equal(t, cmd, dispatched.GetInner())
+	assert.Empty(t, cmd.gopher.Name, "the fake shouldn't handle the command")
+}
@@ -3,7 +3,16 @@
 //
 // [Mediator] can be used to [Send] a [Request].
 // The [Request] passes through a [Pipeline] before eventually being handled.
+// Plain request types can be handled by a [RequestHandler] that is registered with [RegisterHandler],
+// these requests are sent using [SendRequest].
+// For CQRS, a [Command] is sent using [Dispatch] and a [Query] using [SendQuery].
+// Commands and queries pass through their own [Pipeline], see [WithCommandBehaviors] and [WithQueryBehaviors].
 //
 // The [Mediator] object can also be used to [Publish] and [Subscribe] to notifications.
-// It does this without using the reflect package.
+// Handlers are looked up by the type of the notification, they are resolved once per type and cached until the subscribers change.
+// Handlers can also subscribe to every notification that implements an interface using [SubscribeInterface].
+//
+// Every [Message] carries [Metadata], like a message id and a correlation id.
+// Messages that are sent or published while handling another message inherit its correlation id,
+// so a whole flow can be traced through the logs.
 package mediator
@@ -4,9 +4,10 @@ import "log/slog"
 
 type (
 	fakeMediator struct {
+		*notifierRegistry
 		l                  *slog.Logger
 		handleFunc         Handler
-		notifiers          map[any][]any
+		handlers           map[any]any
 		defaultPublishOpts *publishOptions
 	}
 )
@@ -18,6 +19,18 @@ func (f fakeMediator) Then(_ HandlerFunc) Handler {
 func (f fakeMediator) getRequestPipeline() Pipeline {
 	return f
 }
+func (f fakeMediator) getTypedBehaviors(_ typedKey) any {
+	return nil
+}
+
+func (f fakeMediator) getQueryPipeline() Pipeline {
+	return f
+}
+
+func (f fakeMediator) getCommandPipeline() Pipeline {
+	return f
+}
+
 func (f fakeMediator) getNotificationPipeline() Pipeline {
 	return f
 }
@@ -26,12 +39,13 @@ func (f fakeMediator) getLogger() *slog.Logger {
 	return f.l
 }
 
-func (f fakeMediator) getAllNotifiers() map[any][]any {
-	return f.notifiers
+func (f fakeMediator) setRequestHandler(key any, handler any) {
+	f.handlers[key] = handler
 }
 
-func (f fakeMediator) newNotifier(key any, notifier any) {
-	f.notifiers[key] = append(f.notifiers[key], notifier)
+func (f fakeMediator) getRequestHandler(key any) (any, bool) {
+	h, ok := f.handlers[key]
+	return h, ok
 }
 
 func (f fakeMediator) getDefaultPublishOpts() *publishOptions {
@@ -45,12 +59,13 @@ func (f fakeMediator) getDefaultPublishOpts() *publishOptions {
 func NewFake(handleFunc HandlerFunc) Mediator {
 	l := slog.Default()
 	return fakeMediator{
-		l:          l,
-		handleFunc: handleFunc,
-		notifiers:  make(map[any][]any),
+		l:                l,
+		handleFunc:       handleFunc,
+		notifierRegistry: newNotifierRegistry(),
+		handlers:         make(map[any]any),
 		defaultPublishOpts: &publishOptions{
-			l:              l,
-			enableParallel: false,
+			l:        l,
+			strategy: SequentialRunAll(),
 		},
 	}
 }
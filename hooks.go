@@ -0,0 +1,247 @@
+package mediator
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+type (
+	// PreProcessor runs before a request of type Req is handled.
+	// It can change the request by returning a different one, or reject it by returning an error.
+	PreProcessor[Req any] interface {
+		Process(ctx context.Context, l *slog.Logger, req Req) (Req, error)
+	}
+
+	// PostProcessor runs after a request of type Req is handled successfully, it receives the typed response.
+	// Returning an error makes the request fail.
+	PostProcessor[Req any, Resp any] interface {
+		Process(ctx context.Context, l *slog.Logger, req Req, resp Resp) error
+	}
+
+	// ExceptionHandler handles an error of a request of type Req.
+	// It can turn the error into a fallback response by returning a nil error,
+	// or rethrow it by returning the same or another error.
+	// The next [ExceptionHandler] receives the rethrown error.
+	ExceptionHandler[Req any, Resp any] interface {
+		Handle(ctx context.Context, l *slog.Logger, req Req, err error) (Resp, error)
+	}
+
+	// ExceptionAction runs a side effect, like logging or recording a metric, when a request of type Req fails.
+	// Actions run before the [ExceptionHandler] instances and can't change the outcome of the request.
+	ExceptionAction[Req any] interface {
+		Execute(ctx context.Context, l *slog.Logger, req Req, err error)
+	}
+
+	preProcessor[Req any] struct {
+		processFunc func(ctx context.Context, l *slog.Logger, req Req) (Req, error)
+	}
+	postProcessor[Req any, Resp any] struct {
+		processFunc func(ctx context.Context, l *slog.Logger, req Req, resp Resp) error
+	}
+	exceptionHandler[Req any, Resp any] struct {
+		handleFunc func(ctx context.Context, l *slog.Logger, req Req, err error) (Resp, error)
+	}
+	exceptionAction[Req any] struct {
+		executeFunc func(ctx context.Context, l *slog.Logger, req Req, err error)
+	}
+
+	// hooks runs the hooks of a request type around the next handler.
+	// It implements [RequestBehavior], so the hooks of a request type are part of its typed behaviors.
+	hooks[Req any, Resp any] struct {
+		pre               []PreProcessor[Req]
+		post              []PostProcessor[Req, Resp]
+		exceptionHandlers []ExceptionHandler[Req, Resp]
+		exceptionActions  []ExceptionAction[Req]
+	}
+
+	// globalHooks is the [Behavior] that runs the hooks for every request.
+	globalHooks struct {
+		hooks[Message, any]
+	}
+)
+
+func (p preProcessor[Req]) Process(ctx context.Context, l *slog.Logger, req Req) (Req, error) {
+	return p.processFunc(ctx, l, req)
+}
+
+func (p postProcessor[Req, Resp]) Process(ctx context.Context, l *slog.Logger, req Req, resp Resp) error {
+	return p.processFunc(ctx, l, req, resp)
+}
+
+func (h exceptionHandler[Req, Resp]) Handle(ctx context.Context, l *slog.Logger, req Req, err error) (Resp, error) {
+	return h.handleFunc(ctx, l, req, err)
+}
+
+func (a exceptionAction[Req]) Execute(ctx context.Context, l *slog.Logger, req Req, err error) {
+	a.executeFunc(ctx, l, req, err)
+}
+
+// NewPreProcessor is a utility function for creating a [PreProcessor] without having to define a type.
+func NewPreProcessor[Req any](processFunc func(ctx context.Context, l *slog.Logger, req Req) (Req, error)) PreProcessor[Req] {
+	return preProcessor[Req]{processFunc: processFunc}
+}
+
+// NewPostProcessor is a utility function for creating a [PostProcessor] without having to define a type.
+func NewPostProcessor[Req any, Resp any](processFunc func(ctx context.Context, l *slog.Logger, req Req, resp Resp) error) PostProcessor[Req, Resp] {
+	return postProcessor[Req, Resp]{processFunc: processFunc}
+}
+
+// NewExceptionHandler is a utility function for creating an [ExceptionHandler] without having to define a type.
+func NewExceptionHandler[Req any, Resp any](handleFunc func(ctx context.Context, l *slog.Logger, req Req, err error) (Resp, error)) ExceptionHandler[Req, Resp] {
+	return exceptionHandler[Req, Resp]{handleFunc: handleFunc}
+}
+
+// NewExceptionAction is a utility function for creating an [ExceptionAction] without having to define a type.
+func NewExceptionAction[Req any](executeFunc func(ctx context.Context, l *slog.Logger, req Req, err error)) ExceptionAction[Req] {
+	return exceptionAction[Req]{executeFunc: executeFunc}
+}
+
+// Handle runs the pre-processors, the next handler and the post-processors.
+// If one of them fails, the exception actions and handlers are run.
+func (h *hooks[Req, Resp]) Handle(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error) {
+	resp, err := h.process(ctx, l, req, next)
+	if err == nil {
+		return resp, nil
+	}
+	for _, a := range h.exceptionActions {
+		a.Execute(ctx, l, req, err)
+	}
+	for _, eh := range h.exceptionHandlers {
+		resp, err = eh.Handle(ctx, l, req, err)
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return resp, err
+}
+
+func (h *hooks[Req, Resp]) process(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error) {
+	var err error
+	for _, p := range h.pre {
+		if req, err = p.Process(ctx, l, req); err != nil {
+			var zero Resp
+			return zero, err
+		}
+	}
+	resp, err := next(ctx, l, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, p := range h.post {
+		if err := p.Process(ctx, l, req, resp); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// Handler runs the global hooks.
+func (g *globalHooks) Handler(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, l *slog.Logger, msg Message) (any, error) {
+		return g.Handle(ctx, l, msg, next.Handle)
+	})
+}
+
+// typedHooksFor returns the hooks of the request type, they are added to the typed behaviors when they're created.
+func typedHooksFor[Req any, Resp any](o *options) *hooks[Req, Resp] {
+	k := typedKey{req: reflect.TypeFor[Req](), resp: reflect.TypeFor[Resp]()}
+	if h, ok := o.typedHooks[k].(*hooks[Req, Resp]); ok {
+		return h
+	}
+	if o.typedHooks == nil {
+		o.typedHooks = make(map[typedKey]any)
+	}
+	h := &hooks[Req, Resp]{}
+	o.typedHooks[k] = h
+	WithBehaviorsFor[Req, Resp](h)(o)
+	return h
+}
+
+// globalHooksFor returns the global hooks of the options.
+func globalHooksFor(o *options) *globalHooks {
+	if o.hooks == nil {
+		o.hooks = &globalHooks{}
+	}
+	return o.hooks
+}
+
+// WithPreProcessorsFor adds [PreProcessor] instances for requests of type Req that respond with Resp.
+// Like the other hooks for a request type, they run as a [RequestBehavior],
+// at the position of the first hook that was registered for the type.
+// The hooks of a [Command] use struct{} as Resp, they run when the command is sent with [Dispatch].
+//
+// The response type can't be inferred, so it has to be passed explicitly:
+//
+//	m := mediator.New(
+//		mediator.WithPreProcessorsFor[CreateOrder, Order](normalizeOrder),
+//		mediator.WithPreProcessorsFor[CancelOrder, struct{}](checkCancellable),
+//	)
+func WithPreProcessorsFor[Req any, Resp any](processors ...PreProcessor[Req]) Option {
+	return func(o *options) {
+		h := typedHooksFor[Req, Resp](o)
+		h.pre = append(h.pre, processors...)
+	}
+}
+
+// WithPostProcessorsFor adds [PostProcessor] instances for requests of type Req that respond with Resp.
+func WithPostProcessorsFor[Req any, Resp any](processors ...PostProcessor[Req, Resp]) Option {
+	return func(o *options) {
+		h := typedHooksFor[Req, Resp](o)
+		h.post = append(h.post, processors...)
+	}
+}
+
+// WithExceptionHandlersFor adds [ExceptionHandler] instances for requests of type Req that respond with Resp.
+func WithExceptionHandlersFor[Req any, Resp any](handlers ...ExceptionHandler[Req, Resp]) Option {
+	return func(o *options) {
+		h := typedHooksFor[Req, Resp](o)
+		h.exceptionHandlers = append(h.exceptionHandlers, handlers...)
+	}
+}
+
+// WithExceptionActionsFor adds [ExceptionAction] instances for requests of type Req that respond with Resp.
+// The response type can't be inferred, so it has to be passed explicitly.
+func WithExceptionActionsFor[Req any, Resp any](actions ...ExceptionAction[Req]) Option {
+	return func(o *options) {
+		h := typedHooksFor[Req, Resp](o)
+		h.exceptionActions = append(h.exceptionActions, actions...)
+	}
+}
+
+// WithPreProcessors adds [PreProcessor] instances for every request, command and query.
+//
+// The global hooks run after the behaviors of the default [Pipeline], right before the request is handled.
+// They aren't used if the [Pipeline] is replaced with [WithRequestPipeline].
+func WithPreProcessors(processors ...PreProcessor[Message]) Option {
+	return func(o *options) {
+		h := globalHooksFor(o)
+		h.pre = append(h.pre, processors...)
+	}
+}
+
+// WithPostProcessors adds [PostProcessor] instances for every request, command and query.
+// The response of a command is always nil.
+func WithPostProcessors(processors ...PostProcessor[Message, any]) Option {
+	return func(o *options) {
+		h := globalHooksFor(o)
+		h.post = append(h.post, processors...)
+	}
+}
+
+// WithExceptionHandlers adds [ExceptionHandler] instances for every request, command and query.
+// A fallback response has to be of the response type of the request, otherwise a [ResponseTypeError] is returned.
+func WithExceptionHandlers(handlers ...ExceptionHandler[Message, any]) Option {
+	return func(o *options) {
+		h := globalHooksFor(o)
+		h.exceptionHandlers = append(h.exceptionHandlers, handlers...)
+	}
+}
+
+// WithExceptionActions adds [ExceptionAction] instances for every request, command and query.
+func WithExceptionActions(actions ...ExceptionAction[Message]) Option {
+	return func(o *options) {
+		h := globalHooksFor(o)
+		h.exceptionActions = append(h.exceptionActions, actions...)
+	}
+}
@@ -0,0 +1,198 @@
+package mediator_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+func TestPreProcessor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errNoName := errors.New("a gopher needs a name")
+	m := mediator.New(mediator.WithPreProcessorsFor[searchGopher, Gopher](
+		mediator.NewPreProcessor(func(_ context.Context, _ *slog.Logger, req searchGopher) (searchGopher, error) {
+			if req.name == "" {
+				return req, errNoName
+			}
+			return req, nil
+		}),
+		mediator.NewPreProcessor(func(_ context.Context, _ *slog.Logger, req searchGopher) (searchGopher, error) {
+			req.name = strings.TrimSpace(req.name)
+			return req, nil
+		}),
+	))
+
+	gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("  Gus "))
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name, "the pre-processor should be able to change the request")
+
+	_, err = mediator.Send(ctx, m, NewSearchGopherQuery(""))
+	require.ErrorIs(t, err, errNoName)
+}
+
+func TestPostProcessor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var seen []Gopher
+	errNotCute := errors.New("gopher isn't cute enough")
+	m := mediator.New(mediator.WithPostProcessorsFor(
+		mediator.NewPostProcessor(func(_ context.Context, _ *slog.Logger, _ searchGopher, resp Gopher) error {
+			seen = append(seen, resp)
+			return nil
+		}),
+		mediator.NewPostProcessor(func(_ context.Context, _ *slog.Logger, _ searchGopher, resp Gopher) error {
+			if resp.CutenessLevel < 1000 {
+				return errNotCute
+			}
+			return nil
+		}),
+	))
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.ErrorIs(t, err, errNotCute)
+	require.Len(t, seen, 1)
+	assert.Equal(t, "Gus", seen[0].Name)
+}
+
+func TestExceptionHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errNotFound := errors.New("gopher not found")
+	errUnavailable := errors.New("gopher service unavailable")
+
+	var actions []error
+	m := mediator.New(
+		mediator.WithExceptionActionsFor[findGopher, Gopher](
+			mediator.NewExceptionAction(func(_ context.Context, _ *slog.Logger, _ findGopher, err error) {
+				actions = append(actions, err)
+			}),
+		),
+		mediator.WithExceptionHandlersFor(
+			mediator.NewExceptionHandler(func(_ context.Context, _ *slog.Logger, req findGopher, err error) (Gopher, error) {
+				if errors.Is(err, errNotFound) {
+					return Gopher{Name: req.name, Color: "unknown"}, nil
+				}
+				return Gopher{}, err
+			}),
+		),
+	)
+
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		if req.name == "Gus" {
+			return Gopher{}, errNotFound
+		}
+		return Gopher{}, errUnavailable
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	gopher, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, Gopher{Name: "Gus", Color: "unknown"}, gopher, "the error should be turned into a fallback response")
+
+	_, err = mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gert"})
+	require.ErrorIs(t, err, errUnavailable, "other errors should be rethrown")
+
+	assert.Equal(t, []error{errNotFound, errUnavailable}, actions, "actions should run for every error")
+}
+
+func TestHooks_Command(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errHibernating := errors.New("gopher is hibernating")
+
+	var renamed, failed []string
+	m := mediator.New(
+		mediator.WithPreProcessorsFor[*renameGopher, struct{}](
+			mediator.NewPreProcessor(func(_ context.Context, _ *slog.Logger, cmd *renameGopher) (*renameGopher, error) {
+				cmd.name = strings.TrimSpace(cmd.name)
+				return cmd, nil
+			}),
+		),
+		mediator.WithPostProcessorsFor(
+			mediator.NewPostProcessor(func(_ context.Context, _ *slog.Logger, cmd *renameGopher, _ struct{}) error {
+				renamed = append(renamed, cmd.gopher.Name)
+				return nil
+			}),
+		),
+		mediator.WithExceptionActionsFor[*renameGopher, struct{}](
+			mediator.NewExceptionAction(func(_ context.Context, _ *slog.Logger, cmd *renameGopher, _ error) {
+				failed = append(failed, cmd.name)
+			}),
+		),
+	)
+
+	require.NoError(t, mediator.Dispatch(ctx, m, &renameGopher{name: "  Gus "}))
+	err := mediator.Dispatch(ctx, m, &renameGopher{name: "Gert", err: errHibernating})
+	require.ErrorIs(t, err, errHibernating)
+
+	assert.Equal(t, []string{"Gus"}, renamed, "the hooks of the command type should run")
+	assert.Equal(t, []string{"Gert"}, failed)
+}
+
+func TestGlobalHooks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls []string
+	cmdErr := errors.New("gopher is hibernating")
+
+	m := mediator.New(
+		mediator.WithRequestBehaviors(recordBehavior("behavior", &calls)),
+		mediator.WithPreProcessors(mediator.NewPreProcessor(func(_ context.Context, _ *slog.Logger, msg mediator.Message) (mediator.Message, error) {
+			calls = append(calls, "pre:"+msg.Type().String())
+			return msg, nil
+		})),
+		mediator.WithPostProcessors(mediator.NewPostProcessor(func(_ context.Context, _ *slog.Logger, msg mediator.Message, _ any) error {
+			calls = append(calls, "post:"+msg.Type().String())
+			return nil
+		})),
+		mediator.WithExceptionActions(mediator.NewExceptionAction(func(_ context.Context, _ *slog.Logger, msg mediator.Message, _ error) {
+			calls = append(calls, "action:"+msg.Type().String())
+		})),
+		mediator.WithExceptionHandlers(mediator.NewExceptionHandler(func(_ context.Context, _ *slog.Logger, _ mediator.Message, err error) (any, error) {
+			calls = append(calls, "exception")
+			return nil, err
+		})),
+	)
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"behavior:request", "pre:request", "post:request"}, calls)
+
+	calls = nil
+	err = mediator.Dispatch(ctx, m, &renameGopher{err: cmdErr})
+	require.ErrorIs(t, err, cmdErr)
+	assert.Equal(t, []string{"behavior:command", "pre:command", "action:command", "exception"}, calls)
+}
+
+func TestGlobalHooks_Reject(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errForbidden := errors.New("forbidden")
+	m := mediator.New(mediator.WithPreProcessors(mediator.NewPreProcessor(func(_ context.Context, _ *slog.Logger, msg mediator.Message) (mediator.Message, error) {
+		if msg.Type() == mediator.TypeCommand {
+			return msg, errForbidden
+		}
+		return msg, nil
+	})))
+
+	cmd := &renameGopher{name: "Gus"}
+	require.ErrorIs(t, mediator.Dispatch(ctx, m, cmd), errForbidden)
+	assert.Empty(t, cmd.gopher.Name, "a rejected command shouldn't be handled")
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package mediator
 
 import (
 	"log/slog"
+	"slices"
 	"sync"
 )
 
@@ -15,32 +16,52 @@ type (
 		Sender
 	}
 	mediator struct {
+		*notifierRegistry
 		l                    *slog.Logger
 		requestPipeline      Pipeline
+		queryPipeline        Pipeline
+		commandPipeline      Pipeline
 		notificationPipeline Pipeline
 		defaultPublishOpts   *publishOptions
-		notifiers            map[any][]any
-		notifiersMu          sync.RWMutex
+		handlers             map[any]any
+		handlersMu           sync.RWMutex
+		// typedBehaviors isn't changed after New, so it doesn't need a lock
+		typedBehaviors map[typedKey]any
 	}
 	key[T any] struct{}
+	// handlerKey is the key of a [RequestHandler], so handlers with different response types don't replace each other.
+	handlerKey[Req any, Resp any] struct{}
 )
 
-func (m *mediator) newNotifier(key any, notifier any) {
-	m.notifiersMu.Lock()
-	m.notifiers[key] = append(m.notifiers[key], notifier)
-	m.notifiersMu.Unlock()
+func (m *mediator) setRequestHandler(key any, handler any) {
+	m.handlersMu.Lock()
+	m.handlers[key] = handler
+	m.handlersMu.Unlock()
 }
 
-func (m *mediator) getAllNotifiers() map[any][]any {
-	m.notifiersMu.RLock()
-	defer m.notifiersMu.RUnlock()
-	return m.notifiers
+func (m *mediator) getRequestHandler(key any) (any, bool) {
+	m.handlersMu.RLock()
+	defer m.handlersMu.RUnlock()
+	h, ok := m.handlers[key]
+	return h, ok
 }
 
 func (m *mediator) getRequestPipeline() Pipeline {
 	return m.requestPipeline
 }
 
+func (m *mediator) getTypedBehaviors(key typedKey) any {
+	return m.typedBehaviors[key]
+}
+
+func (m *mediator) getQueryPipeline() Pipeline {
+	return m.queryPipeline
+}
+
+func (m *mediator) getCommandPipeline() Pipeline {
+	return m.commandPipeline
+}
+
 func (m *mediator) getNotificationPipeline() Pipeline {
 	return m.notificationPipeline
 }
@@ -54,6 +75,20 @@ func (m *mediator) getDefaultPublishOpts() *publishOptions {
 	return m.defaultPublishOpts
 }
 
+// kindPipeline creates the [Pipeline] for a kind of request, like a [Query] or [Command].
+// It runs the request behaviors first, followed by the behaviors of the kind and the global hooks.
+// The hooks are only used by the default [Pipeline].
+func kindPipeline(requestPipeline Pipeline, requestBehaviors, kindBehaviors, hookBehaviors []Behavior) Pipeline {
+	if len(kindBehaviors) == 0 {
+		// reuse the request pipeline, so its chain isn't built again
+		return requestPipeline
+	}
+	if _, ok := requestPipeline.(pipeline); ok {
+		return newPipeline(slices.Concat(requestBehaviors, kindBehaviors, hookBehaviors)...)
+	}
+	return nestedPipeline{outer: requestPipeline, inner: pipeline{behaviors: kindBehaviors}}
+}
+
 // New creates a new [Mediator].
 // The [Mediator] can be customized with the [Option] slice parameter.
 func New(opt ...Option) Mediator {
@@ -62,14 +97,25 @@ func New(opt ...Option) Mediator {
 		requestBehaviors:      []Behavior{},
 		notificationBehaviors: []Behavior{},
 		l:                     slog.Default(),
-		parallelNotifications: false,
+		publishStrategy:       SequentialRunAll(),
 	}
 	for _, o := range opt {
 		o(opts)
 	}
+	queryBehaviors := opts.queryBehaviors
+	if opts.readOnlyQueries {
+		queryBehaviors = append([]Behavior{readOnlyGuard{}}, queryBehaviors...)
+	}
+	// the global hooks run after the other behaviors, right before the request is handled
+	var hookBehaviors []Behavior
+	if opts.hooks != nil {
+		hookBehaviors = []Behavior{opts.hooks}
+	}
 	if opts.requestPipeline == nil {
-		opts.requestPipeline = newPipeline(opts.requestBehaviors...)
+		opts.requestPipeline = newPipeline(slices.Concat(opts.requestBehaviors, hookBehaviors)...)
 	}
+	queryPipeline := kindPipeline(opts.requestPipeline, opts.requestBehaviors, queryBehaviors, hookBehaviors)
+	commandPipeline := kindPipeline(opts.requestPipeline, opts.requestBehaviors, opts.commandBehaviors, hookBehaviors)
 	if opts.notificationPipeline == nil {
 		opts.notificationPipeline = newPipeline(opts.notificationBehaviors...)
 	}
@@ -77,11 +123,17 @@ func New(opt ...Option) Mediator {
 	return &mediator{
 		l:                    opts.l,
 		requestPipeline:      opts.requestPipeline,
+		queryPipeline:        queryPipeline,
+		commandPipeline:      commandPipeline,
 		notificationPipeline: opts.notificationPipeline,
-		notifiers:            make(map[any][]any),
+		notifierRegistry:     newNotifierRegistry(),
+		handlers:             make(map[any]any),
+		typedBehaviors:       opts.typedBehaviors,
 		defaultPublishOpts: &publishOptions{
-			l:              opts.l,
-			enableParallel: opts.parallelNotifications,
+			l:        opts.l,
+			strategy: opts.publishStrategy,
+			pool:     opts.workerPool,
+			outbox:   opts.outbox,
 		},
 	}
 }
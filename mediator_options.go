@@ -9,9 +9,17 @@ type (
 		l                     *slog.Logger
 		requestBehaviors      []Behavior
 		requestPipeline       Pipeline
+		queryBehaviors        []Behavior
+		commandBehaviors      []Behavior
+		readOnlyQueries       bool
+		typedBehaviors        map[typedKey]any
+		typedHooks            map[typedKey]any
+		hooks                 *globalHooks
 		notificationBehaviors []Behavior
 		notificationPipeline  Pipeline
-		parallelNotifications bool
+		publishStrategy       PublishStrategy
+		workerPool            *WorkerPool
+		outbox                Outbox
 	}
 )
 
@@ -32,12 +40,40 @@ func WithRequestBehaviors(behaviors ...Behavior) Option {
 
 // WithRequestPipeline overwrites the default [Pipeline] with the given implementation.
 // If this option is set, other pipeline options like [WithRequestBehaviors] are ignored.
+// The behaviors of [WithQueryBehaviors] and [WithCommandBehaviors] are chained after the given [Pipeline].
 func WithRequestPipeline(pipeline Pipeline) Option {
 	return func(o *options) {
 		o.requestPipeline = pipeline
 	}
 }
 
+// WithQueryBehaviors adds behaviors that only run for a [Query].
+// The query [Pipeline] runs the request behaviors first, followed by these behaviors.
+// This is useful for behaviors that only make sense for reads, like caching.
+func WithQueryBehaviors(behaviors ...Behavior) Option {
+	return func(o *options) {
+		o.queryBehaviors = behaviors
+	}
+}
+
+// WithCommandBehaviors adds behaviors that only run for a [Command].
+// The command [Pipeline] runs the request behaviors first, followed by these behaviors.
+// This is useful for behaviors that only make sense for writes, like transactions.
+func WithCommandBehaviors(behaviors ...Behavior) Option {
+	return func(o *options) {
+		o.commandBehaviors = behaviors
+	}
+}
+
+// WithReadOnlyQueries makes queries read-only.
+// A [Command] that is dispatched with the context of a [Query] is rejected with an [ErrReadOnly] error.
+// This includes commands that are dispatched by requests or notifications that the query sends or publishes.
+func WithReadOnlyQueries() Option {
+	return func(o *options) {
+		o.readOnlyQueries = true
+	}
+}
+
 // WithNotificationBehaviors adds behaviors to the [Notification] [Pipeline].
 func WithNotificationBehaviors(behaviors ...Behavior) Option {
 	return func(o *options) {
@@ -55,8 +91,35 @@ func WithNotificationPipeline(pipeline Pipeline) Option {
 
 // WithParallelNotifications enables calling notification handlers in parallel by default.
 // Can be overwritten by [WithParallelEnabled].
+//
+// This is the same as using [WithPublishStrategy] with the [ParallelWaitAll] strategy.
 func WithParallelNotifications() Option {
 	return func(o *options) {
-		o.parallelNotifications = true
+		o.publishStrategy = ParallelWaitAll()
+	}
+}
+
+// WithPublishStrategy sets the default [PublishStrategy] that decides how notification handlers are called.
+// Can be overwritten for a single [Publish] call by [WithStrategy].
+func WithPublishStrategy(strategy PublishStrategy) Option {
+	return func(o *options) {
+		o.publishStrategy = strategy
+	}
+}
+
+// WithWorkerPool runs the notification handlers of parallel [PublishStrategy] implementations on the given [WorkerPool],
+// instead of starting a new goroutine for every handler.
+// The pool is not shut down by the [Mediator], the caller is responsible for calling [WorkerPool.Shutdown].
+func WithWorkerPool(pool *WorkerPool) Option {
+	return func(o *options) {
+		o.workerPool = pool
+	}
+}
+
+// WithPublishOutbox stores every published notification in the [Outbox] by default, instead of publishing it.
+// Can be overwritten for a single [Publish] call by [WithOutbox].
+func WithPublishOutbox(outbox Outbox) Option {
+	return func(o *options) {
+		o.outbox = outbox
 	}
 }
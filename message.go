@@ -2,6 +2,7 @@ package mediator
 
 import (
 	"context"
+	"iter"
 	"log/slog"
 	"reflect"
 )
@@ -14,6 +15,10 @@ const (
 	TypeRequest MessageType = iota
 	// TypeNotification is the type for [Notification] messages.
 	TypeNotification
+	// TypeCommand is the type for [Command] messages.
+	TypeCommand
+	// TypeQuery is the type for [Query] messages.
+	TypeQuery
 )
 
 func (t MessageType) String() string {
@@ -22,6 +27,10 @@ func (t MessageType) String() string {
 		return "request"
 	case TypeNotification:
 		return "notification"
+	case TypeCommand:
+		return "command"
+	case TypeQuery:
+		return "query"
 	default:
 		return "unknown"
 	}
@@ -42,6 +51,9 @@ type (
 		Type() MessageType
 		// GetInner returns the object that is wrapped in the [Message] interface.
 		GetInner() any
+		// Metadata returns the [Metadata] of this [Message].
+		// Behaviors can read and add values, the map is shared by the whole [Pipeline].
+		Metadata() Metadata
 	}
 	// RequestMessage extends the [Message] interface with the [Request] interface.
 	// It is the request version of the [Message] interface.
@@ -51,8 +63,58 @@ type (
 		GetRequest() Request[T]
 	}
 	requestMessage[T any] struct {
-		name string
-		req  Request[T]
+		name     string
+		req      Request[T]
+		metadata Metadata
+		// typed holds the request behaviors of the request type, it is nil if there are none
+		typed requestChain[T]
+	}
+	// handlerRequestMessage wraps requests that are handled by a registered [RequestHandler].
+	handlerRequestMessage[Req any, Resp any] struct {
+		name     string
+		req      Req
+		handler  RequestHandler[Req, Resp]
+		metadata Metadata
+		typed    typedBehaviors[Req, Resp]
+	}
+	// StreamRequestMessage extends the [Message] interface with the [StreamRequest] interface.
+	// It is the stream request version of the [Message] interface.
+	StreamRequestMessage[T any] interface {
+		Message
+		StreamRequest[T]
+		GetStreamRequest() StreamRequest[T]
+	}
+	streamRequestMessage[T any] struct {
+		name     string
+		req      StreamRequest[T]
+		metadata Metadata
+		// consume ranges over the stream at the end of the pipeline.
+		consume func(ctx context.Context, l *slog.Logger) (any, error)
+	}
+	// QueryMessage extends the [Message] interface with the [Query] interface.
+	// It is the query version of the [Message] interface.
+	QueryMessage[T any] interface {
+		Message
+		Query[T]
+		GetQuery() Query[T]
+	}
+	// queryMessage is a [requestMessage] with the [TypeQuery] type.
+	queryMessage[T any] struct {
+		requestMessage[T]
+	}
+	// CommandMessage extends the [Message] interface with the [Command] interface.
+	// It is the command version of the [Message] interface.
+	CommandMessage interface {
+		Message
+		Command
+		GetCommand() Command
+	}
+	commandMessage struct {
+		name     string
+		cmd      Command
+		metadata Metadata
+		// typed are the request behaviors of the command type, it is nil if there are none
+		typed commandChain
 	}
 	// NotificationMessage extends the [Message] interface with the [Notification] interface.
 	// It is the notification version of the [Message] interface.
@@ -64,6 +126,16 @@ type (
 	notificationMessage[T any] struct {
 		name         string
 		notification Notification[T]
+		handler      NotificationHandler[T]
+		metadata     Metadata
+		// scoped holds the metadata of the handler of a published notification, it is used instead of metadata when it is set
+		scoped *handlerMetadata
+	}
+
+	// terminalMessage is implemented by messages that know how they are handled at the end of the [Pipeline].
+	// Because the message carries its final handler, the default [Pipeline] only has to build its chain once.
+	terminalMessage interface {
+		handleTerminal(ctx context.Context, l *slog.Logger) (any, error)
 	}
 )
 
@@ -85,6 +157,10 @@ func (r requestMessage[T]) String() string {
 	return r.name
 }
 
+func (r requestMessage[T]) Metadata() Metadata {
+	return r.metadata
+}
+
 func (r requestMessage[T]) Type() MessageType {
 	return TypeRequest
 }
@@ -93,10 +169,206 @@ func (r requestMessage[T]) GetRequest() Request[T] {
 	return r.req
 }
 
+func (r requestMessage[T]) checkResponse(resp any) *ResponseTypeError {
+	rtErr := checkResponse[T](resp)
+	if rtErr != nil {
+		rtErr.Request = r.String()
+	}
+	return rtErr
+}
+
+func (r requestMessage[T]) zeroResponse() any {
+	var zero T
+	return zero
+}
+
+func (r requestMessage[T]) handleTerminal(ctx context.Context, l *slog.Logger) (any, error) {
+	if r.typed != nil {
+		return r.typed.handleRequest(ctx, l, r.req)
+	}
+	return r.req.Handle(ctx, l)
+}
+
 // NewRequestMessage wraps a [Request] so it implements the [Message] and [RequestMessage] interfaces.
 func NewRequestMessage[T any](req Request[T]) RequestMessage[T] {
 	return requestMessage[T]{
-		req: req,
+		req:      req,
+		metadata: Metadata{},
+	}
+}
+
+// handlerRequestMessage implementation
+
+func (r handlerRequestMessage[Req, Resp]) GetInner() any {
+	return r.req
+}
+
+func (r handlerRequestMessage[Req, Resp]) String() string {
+	// don't do any unnecessary reflect calls
+	if len(r.name) == 0 {
+		r.name = reflect.TypeOf(r.req).Name()
+	}
+	return r.name
+}
+
+func (r handlerRequestMessage[Req, Resp]) Metadata() Metadata {
+	return r.metadata
+}
+
+func (r handlerRequestMessage[Req, Resp]) Type() MessageType {
+	return TypeRequest
+}
+
+func (r handlerRequestMessage[Req, Resp]) checkResponse(resp any) *ResponseTypeError {
+	rtErr := checkResponse[Resp](resp)
+	if rtErr != nil {
+		rtErr.Request = r.String()
+	}
+	return rtErr
+}
+
+func (r handlerRequestMessage[Req, Resp]) zeroResponse() any {
+	var zero Resp
+	return zero
+}
+
+func (r handlerRequestMessage[Req, Resp]) handleTerminal(ctx context.Context, l *slog.Logger) (any, error) {
+	if r.typed != nil {
+		return r.typed.handle(ctx, l, r.req, r.handle)
+	}
+	return r.handle(ctx, l, r.req)
+}
+
+func (r handlerRequestMessage[Req, Resp]) handle(ctx context.Context, l *slog.Logger, req Req) (Resp, error) {
+	if r.handler != nil {
+		return r.handler.Handle(ctx, l, req)
+	}
+	if req, ok := any(req).(Request[Resp]); ok {
+		return req.Handle(ctx, l)
+	}
+	var zero Resp
+	return zero, ErrNoHandler{Request: r.String()}
+}
+
+// newHandlerRequestMessage wraps a request that is handled by the given handler.
+// If the handler is nil, the request has to implement [Request] to be handled.
+func newHandlerRequestMessage[Req any, Resp any](req Req, handler RequestHandler[Req, Resp], md Metadata) handlerRequestMessage[Req, Resp] {
+	return handlerRequestMessage[Req, Resp]{
+		req:      req,
+		handler:  handler,
+		metadata: md,
+	}
+}
+
+// StreamRequestMessage implementation
+
+func (r streamRequestMessage[T]) GetInner() any {
+	return r.req
+}
+
+func (r streamRequestMessage[T]) Handle(ctx context.Context, l *slog.Logger) iter.Seq2[T, error] {
+	return r.req.Handle(ctx, l)
+}
+
+func (r streamRequestMessage[T]) String() string {
+	// don't do any unnecessary reflect calls
+	if len(r.name) == 0 {
+		r.name = reflect.TypeOf(r.req).Name()
+	}
+	return r.name
+}
+
+func (r streamRequestMessage[T]) Metadata() Metadata {
+	return r.metadata
+}
+
+func (r streamRequestMessage[T]) Type() MessageType {
+	return TypeRequest
+}
+
+func (r streamRequestMessage[T]) GetStreamRequest() StreamRequest[T] {
+	return r.req
+}
+
+func (r streamRequestMessage[T]) isStream() {}
+
+func (r streamRequestMessage[T]) handleTerminal(ctx context.Context, l *slog.Logger) (any, error) {
+	if r.consume == nil {
+		return nil, nil
+	}
+	return r.consume(ctx, l)
+}
+
+// NewStreamRequestMessage wraps a [StreamRequest] so it implements the [Message] and [StreamRequestMessage] interfaces.
+func NewStreamRequestMessage[T any](req StreamRequest[T]) StreamRequestMessage[T] {
+	return streamRequestMessage[T]{
+		req:      req,
+		metadata: Metadata{},
+	}
+}
+
+// QueryMessage implementation
+
+func (q queryMessage[T]) Type() MessageType {
+	return TypeQuery
+}
+
+func (q queryMessage[T]) GetQuery() Query[T] {
+	return q.req
+}
+
+// NewQueryMessage wraps a [Query] so it implements the [Message] and [QueryMessage] interfaces.
+func NewQueryMessage[T any](query Query[T]) QueryMessage[T] {
+	return queryMessage[T]{
+		requestMessage: requestMessage[T]{
+			req:      query,
+			metadata: Metadata{},
+		},
+	}
+}
+
+// CommandMessage implementation
+
+func (c commandMessage) GetInner() any {
+	return c.cmd
+}
+
+func (c commandMessage) Handle(ctx context.Context, l *slog.Logger) error {
+	return c.cmd.Handle(ctx, l)
+}
+
+func (c commandMessage) String() string {
+	// don't do any unnecessary reflect calls
+	if len(c.name) == 0 {
+		c.name = reflect.TypeOf(c.cmd).Name()
+	}
+	return c.name
+}
+
+func (c commandMessage) Metadata() Metadata {
+	return c.metadata
+}
+
+func (c commandMessage) Type() MessageType {
+	return TypeCommand
+}
+
+func (c commandMessage) GetCommand() Command {
+	return c.cmd
+}
+
+func (c commandMessage) handleTerminal(ctx context.Context, l *slog.Logger) (any, error) {
+	if c.typed != nil {
+		return nil, c.typed.handleCommand(ctx, l, c.cmd)
+	}
+	return nil, c.cmd.Handle(ctx, l)
+}
+
+// NewCommandMessage wraps a [Command] so it implements the [Message] and [CommandMessage] interfaces.
+func NewCommandMessage(cmd Command) CommandMessage {
+	return commandMessage{
+		cmd:      cmd,
+		metadata: Metadata{},
 	}
 }
 
@@ -114,6 +386,13 @@ func (n notificationMessage[T]) String() string {
 	return n.name
 }
 
+func (n notificationMessage[T]) Metadata() Metadata {
+	if n.scoped != nil {
+		return n.scoped.get()
+	}
+	return n.metadata
+}
+
 func (n notificationMessage[T]) Type() MessageType {
 	return TypeNotification
 }
@@ -122,9 +401,19 @@ func (n notificationMessage[T]) GetNotification() Notification[T] {
 	return n.notification
 }
 
+func (n notificationMessage[T]) handleTerminal(ctx context.Context, l *slog.Logger) (any, error) {
+	if n.handler == nil {
+		return nil, nil
+	}
+	// the notification is nil if T is an interface, the zero value of T is nil as well in that case
+	event, _ := n.notification.(T)
+	return nil, n.handler.Handle(ctx, l, event)
+}
+
 // NewNotificationMessage wraps a [Notification] so it implements the [Message] and [NotificationMessage] interfaces.
 func NewNotificationMessage[T any](notification Notification[T]) NotificationMessage[T] {
 	return notificationMessage[T]{
 		notification: notification,
+		metadata:     Metadata{},
 	}
 }
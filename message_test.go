@@ -25,6 +25,14 @@ func TestMessageType_String(t *testing.T) {
 			t: mediator.TypeNotification,
 			s: "notification",
 		},
+		{
+			t: mediator.TypeCommand,
+			s: "command",
+		},
+		{
+			t: mediator.TypeQuery,
+			s: "query",
+		},
 		{
 			t: mediator.MessageType(999),
 			s: "unknown",
@@ -54,6 +62,53 @@ func TestRequestMessage(t *testing.T) {
 	assert.Equal(t, name, res.Name)
 }
 
+func TestStreamRequestMessage(t *testing.T) {
+	t.Parallel()
+
+	req := listGophers{names: []string{"Gus"}}
+	msg := mediator.NewStreamRequestMessage[Gopher](req)
+
+	assert.Equal(t, req, msg.GetInner())
+	assert.Equal(t, "listGophers", msg.String())
+	assert.Equal(t, mediator.TypeRequest, msg.Type())
+	assert.IsType(t, listGophers{}, msg.GetStreamRequest())
+
+	for res, err := range msg.Handle(context.Background(), slog.Default()) {
+		require.NoError(t, err)
+		assert.Equal(t, "Gus", res.Name)
+	}
+}
+
+func TestQueryMessage(t *testing.T) {
+	t.Parallel()
+
+	query := NewSearchGopherQuery("some-gopher")
+	msg := mediator.NewQueryMessage(query)
+
+	assert.Equal(t, query, msg.GetInner())
+	assert.Equal(t, "searchGopher", msg.String())
+	assert.Equal(t, mediator.TypeQuery, msg.Type())
+	assert.IsType(t, searchGopher{}, msg.GetQuery())
+
+	res, err := msg.Handle(context.Background(), slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, "some-gopher", res.Name)
+}
+
+func TestCommandMessage(t *testing.T) {
+	t.Parallel()
+
+	cmd := &renameGopher{name: "Gus"}
+	msg := mediator.NewCommandMessage(cmd)
+
+	assert.Equal(t, cmd, msg.GetInner())
+	assert.Equal(t, mediator.TypeCommand, msg.Type())
+	assert.Equal(t, cmd, msg.GetCommand())
+
+	require.NoError(t, msg.Handle(context.Background(), slog.Default()))
+	assert.Equal(t, "Gus", cmd.gopher.Name)
+}
+
 func TestNotificationMessage(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,129 @@
+package mediator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"time"
+)
+
+// Metadata holds cross-cutting data of a [Message], like the message id, correlation id, tenant or user.
+// It can be set with [WithSendMetadata] and [WithPublishMetadata], and read in every [Behavior] using [Message.Metadata].
+//
+// While a message is handled, its metadata is available from the context using [MetadataFromContext].
+// A message that is sent or published with that context inherits the correlation id, tenant and user,
+// and gets the id of the parent message as its causation id.
+type Metadata map[string]string
+
+// Well known [Metadata] keys.
+const (
+	// MetadataMessageID is the unique id of the message. It is generated if it isn't set.
+	MetadataMessageID = "message_id"
+	// MetadataCorrelationID is shared by all messages in a flow.
+	// It is inherited from the parent message, or the same as the message id if there is no parent.
+	MetadataCorrelationID = "correlation_id"
+	// MetadataCausationID is the id of the message that caused this message.
+	MetadataCausationID = "causation_id"
+	// MetadataTimestamp is the time the message was created, formatted as [time.RFC3339Nano].
+	MetadataTimestamp = "timestamp"
+	// MetadataTenant is the tenant the message belongs to. It is inherited from the parent message.
+	MetadataTenant = "tenant"
+	// MetadataUser is the user that sent the message. It is inherited from the parent message.
+	MetadataUser = "user"
+)
+
+// Get returns the value for the given key, or an empty string if it isn't set.
+func (md Metadata) Get(key string) string {
+	return md[key]
+}
+
+// Set sets the value for the given key.
+func (md Metadata) Set(key, value string) {
+	md[key] = value
+}
+
+// MessageID returns the [MetadataMessageID] value.
+func (md Metadata) MessageID() string {
+	return md[MetadataMessageID]
+}
+
+// CorrelationID returns the [MetadataCorrelationID] value.
+func (md Metadata) CorrelationID() string {
+	return md[MetadataCorrelationID]
+}
+
+// CausationID returns the [MetadataCausationID] value.
+func (md Metadata) CausationID() string {
+	return md[MetadataCausationID]
+}
+
+// Timestamp parses the [MetadataTimestamp] value. It returns the zero time if it isn't set or invalid.
+func (md Metadata) Timestamp() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, md[MetadataTimestamp])
+	return t
+}
+
+// ContextWithMetadata returns a copy of the context that holds the metadata.
+// Messages that are sent or published with the returned context, inherit from this metadata.
+//
+// The [Mediator] does this for every message it handles, so calling this function is only needed
+// when a flow starts outside the [Mediator], for example to pass on a correlation id from an HTTP header.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	scope := scopeFromContext(ctx)
+	scope.metadata, scope.handler = md, nil
+	return contextWithScope(ctx, scope)
+}
+
+// MetadataFromContext returns the [Metadata] of the message that is being handled, or nil if there is none.
+func MetadataFromContext(ctx context.Context) Metadata {
+	return scopeFromContext(ctx).getMetadata()
+}
+
+// newMetadata creates the metadata for a new message.
+// It inherits from the metadata in the context, the values that are set explicitly take precedence.
+func newMetadata(ctx context.Context, explicit Metadata) Metadata {
+	md := make(Metadata, len(explicit)+4)
+	parent := MetadataFromContext(ctx)
+	for _, k := range []string{MetadataCorrelationID, MetadataTenant, MetadataUser} {
+		if v := parent[k]; v != "" {
+			md[k] = v
+		}
+	}
+	if id := parent[MetadataMessageID]; id != "" {
+		md[MetadataCausationID] = id
+	}
+	maps.Copy(md, explicit)
+
+	if md[MetadataMessageID] == "" {
+		md[MetadataMessageID] = newMessageID()
+	}
+	if md[MetadataCorrelationID] == "" {
+		md[MetadataCorrelationID] = md[MetadataMessageID]
+	}
+	if md[MetadataTimestamp] == "" {
+		var buf [len(time.RFC3339Nano)]byte
+		md[MetadataTimestamp] = string(time.Now().UTC().AppendFormat(buf[:0], time.RFC3339Nano))
+	}
+	return md
+}
+
+// newMessageID generates a random (version 4) UUID.
+func newMessageID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf[:])
+}
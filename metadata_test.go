@@ -0,0 +1,230 @@
+package mediator_test
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestMetadata_Defaults(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var md mediator.Metadata
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		md = mediator.MetadataFromContext(ctx)
+		return Gopher{Name: req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+
+	require.NotNil(t, md)
+	assert.Regexp(t, uuidPattern, md.MessageID())
+	assert.Equal(t, md.MessageID(), md.CorrelationID(), "a message without parent starts a new flow")
+	assert.Empty(t, md.CausationID())
+	assert.False(t, md.Timestamp().IsZero())
+}
+
+func TestMetadata_MessageIDsAreUnique(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var ids []string
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, _ findGopher) (Gopher, error) {
+		ids = append(ids, mediator.MetadataFromContext(ctx).MessageID())
+		return Gopher{}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	for range 3 {
+		_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{})
+		require.NoError(t, err)
+	}
+	require.Len(t, ids, 3)
+	assert.NotEqual(t, ids[0], ids[1])
+	assert.NotEqual(t, ids[1], ids[2])
+}
+
+func TestMetadata_Inheritance(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var parent, child mediator.Metadata
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		parent = mediator.MetadataFromContext(ctx)
+		return Gopher{Name: req.name}, mediator.Publish(ctx, m, "gopher-found")
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+	subscribeFunc(t, m, func(ctx context.Context, _ *slog.Logger, _ string) error {
+		child = mediator.MetadataFromContext(ctx)
+		return nil
+	})
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"}, mediator.WithSendMetadata(mediator.Metadata{
+		mediator.MetadataCorrelationID: "request-123",
+		mediator.MetadataTenant:        "gopher-corp",
+		mediator.MetadataUser:          "gus",
+		"custom":                       "not-inherited",
+	}))
+	require.NoError(t, err)
+
+	require.NotNil(t, parent)
+	require.NotNil(t, child)
+	assert.Equal(t, "request-123", parent.CorrelationID())
+	assert.Equal(t, "request-123", child.CorrelationID())
+	assert.Equal(t, parent.MessageID(), child.CausationID())
+	assert.NotEqual(t, parent.MessageID(), child.MessageID())
+	assert.Equal(t, "gopher-corp", child.Get(mediator.MetadataTenant))
+	assert.Equal(t, "gus", child.Get(mediator.MetadataUser))
+	assert.Empty(t, child.Get("custom"), "only the well known flow values are inherited")
+}
+
+func TestMetadata_InheritanceFromNotification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var parent, child mediator.Metadata
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		child = mediator.MetadataFromContext(ctx)
+		return Gopher{Name: req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+	subscribeFunc(t, m, func(ctx context.Context, _ *slog.Logger, _ string) error {
+		parent = mediator.MetadataFromContext(ctx)
+		_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+		return err
+	})
+
+	require.NoError(t, mediator.Publish(ctx, m, "gopher-wanted"))
+
+	require.NotNil(t, parent)
+	require.NotNil(t, child)
+	assert.Equal(t, parent.MessageID(), child.CausationID())
+	assert.NotEqual(t, parent.MessageID(), child.MessageID())
+}
+
+func TestMetadata_ContextWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := mediator.ContextWithMetadata(context.Background(), mediator.Metadata{
+		mediator.MetadataMessageID:     "http-request",
+		mediator.MetadataCorrelationID: "trace-abc",
+	})
+	m := mediator.New()
+
+	var md mediator.Metadata
+	subscribeFunc(t, m, func(ctx context.Context, _ *slog.Logger, _ string) error {
+		md = mediator.MetadataFromContext(ctx)
+		return nil
+	})
+
+	require.NoError(t, mediator.Publish(ctx, m, "some-event", mediator.WithPublishMetadata(mediator.Metadata{"source": "api"})))
+
+	require.NotNil(t, md)
+	assert.Equal(t, "trace-abc", md.CorrelationID())
+	assert.Equal(t, "http-request", md.CausationID())
+	assert.Equal(t, "api", md.Get("source"))
+}
+
+func TestMetadata_AvailableInBehaviors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var fromMsg mediator.Metadata
+	behav := &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+		fromMsg = msg.Metadata()
+		msg.Metadata().Set("enriched", "yes")
+		return next.Handle(ctx, l, msg)
+	}}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	var fromCtx mediator.Metadata
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, _ findGopher) (Gopher, error) {
+		fromCtx = mediator.MetadataFromContext(ctx)
+		return Gopher{}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{})
+	require.NoError(t, err)
+
+	require.NotNil(t, fromMsg)
+	assert.Equal(t, fromMsg.MessageID(), fromCtx.MessageID())
+	assert.Equal(t, "yes", fromCtx.Get("enriched"), "values added by a behavior should be visible to the handler")
+}
+
+func TestMetadata_ParallelHandlersGetACopy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithParallelNotifications())
+
+	var mu sync.Mutex
+	var ids []string
+	for range 5 {
+		subscribeFunc(t, m, func(ctx context.Context, _ *slog.Logger, _ string) error {
+			md := mediator.MetadataFromContext(ctx)
+			// writing would race if the handlers shared the map
+			md.Set("handled", "yes")
+			mu.Lock()
+			defer mu.Unlock()
+			ids = append(ids, md.MessageID())
+			return nil
+		})
+	}
+
+	require.NoError(t, mediator.Publish(ctx, m, "some-event"))
+
+	require.Len(t, ids, 5)
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id, "all handlers of one publish share the message id")
+	}
+}
+
+func TestMetadata_NotificationHandlersGetACopy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	handled := 0
+	behav := &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+		handled++
+		if msg.Metadata().Get("handler") == "" {
+			msg.Metadata().Set("handler", strconv.Itoa(handled))
+		}
+		return next.Handle(ctx, l, msg)
+	}}
+	m := mediator.New(mediator.WithNotificationBehaviors(behav))
+
+	var seen []string
+	for range 2 {
+		subscribeFunc(t, m, func(ctx context.Context, _ *slog.Logger, _ string) error {
+			seen = append(seen, mediator.MetadataFromContext(ctx).Get("handler"))
+			return nil
+		})
+	}
+
+	md := mediator.Metadata{"source": "api"}
+	require.NoError(t, mediator.Publish(ctx, m, "some-event", mediator.WithPublishMetadata(md)))
+	assert.Equal(t, []string{"1", "2"}, seen, "values added by a behavior should be visible to its handler only")
+	assert.Equal(t, mediator.Metadata{"source": "api"}, md)
+}
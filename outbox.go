@@ -0,0 +1,15 @@
+package mediator
+
+import "context"
+
+// Outbox stores notifications instead of publishing them, so they can be published reliably later.
+// This is the transactional outbox pattern: the notification is stored in the same transaction
+// as the changes of the message that published it, and a relay publishes it after the transaction committed.
+//
+// An outbox is used by [Publish] when it is set with [WithPublishOutbox] or [WithOutbox].
+// The outbox package holds implementations and a relay.
+type Outbox interface {
+	// Store stores the notification with its metadata.
+	// The context is the context that the notification was published with, so it holds the transaction.
+	Store(ctx context.Context, notification any, md Metadata) error
+}
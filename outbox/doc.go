@@ -0,0 +1,6 @@
+// Package outbox implements the transactional outbox pattern for [mediator.Mediator].
+//
+// An [Outbox] is set on the mediator with [mediator.WithPublishOutbox], so notifications are stored in a [Store]
+// in the transaction of the message that publishes them, instead of being published right away.
+// A [Relay] reads the stored notifications and publishes them through the notification [mediator.Pipeline].
+package outbox
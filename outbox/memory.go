@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// MemoryStore is a [Store] that keeps the entries in memory.
+	// It isn't transactional and its entries are lost when the process stops,
+	// so it is meant for tests and for applications that only need the ordering and retries of the [Relay].
+	MemoryStore struct {
+		mu      sync.Mutex
+		lastID  int64
+		entries []memoryEntry
+	}
+
+	memoryEntry struct {
+		entry  Entry
+		status status
+	}
+
+	status string
+)
+
+const (
+	statusPending    status = "pending"
+	statusDispatched status = "dispatched"
+	statusPoisoned   status = "poisoned"
+)
+
+// Add implements [Store].
+func (s *MemoryStore) Add(_ context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	entry.ID = s.lastID
+	entry.Metadata = maps.Clone(entry.Metadata)
+	s.entries = append(s.entries, memoryEntry{entry: entry, status: statusPending})
+	return nil
+}
+
+// Pending implements [Store].
+func (s *MemoryStore) Pending(_ context.Context, now time.Time, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []Entry
+	// waiting holds the aggregates that have an entry that waits for its retry
+	waiting := make(map[string]struct{})
+	for _, e := range s.entries {
+		if len(pending) >= limit {
+			break
+		}
+		if e.status != statusPending {
+			continue
+		}
+		if _, ok := waiting[e.entry.AggregateKey]; ok {
+			continue
+		}
+		if e.entry.RetryAt.After(now) {
+			if e.entry.AggregateKey != "" {
+				waiting[e.entry.AggregateKey] = struct{}{}
+			}
+			continue
+		}
+		pending = append(pending, e.entry)
+	}
+	return pending, nil
+}
+
+// MarkDispatched implements [Store], dispatched entries are removed.
+func (s *MemoryStore) MarkDispatched(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].entry.ID == id {
+			s.entries = slices.Delete(s.entries, i, i+1)
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox entry %d doesn't exist", id)
+}
+
+// MarkFailed implements [Store].
+func (s *MemoryStore) MarkFailed(_ context.Context, id int64, attempts int, retryAt time.Time, reason string) error {
+	return s.update(id, func(e *memoryEntry) {
+		e.entry.Attempts = attempts
+		e.entry.RetryAt = retryAt
+		e.entry.LastError = reason
+	})
+}
+
+// MarkPoisoned implements [Store].
+func (s *MemoryStore) MarkPoisoned(_ context.Context, id int64, attempts int, reason string) error {
+	return s.update(id, func(e *memoryEntry) {
+		e.status = statusPoisoned
+		e.entry.Attempts = attempts
+		e.entry.LastError = reason
+	})
+}
+
+// Poisoned returns the entries that are poisoned.
+func (s *MemoryStore) Poisoned() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var poisoned []Entry
+	for _, e := range s.entries {
+		if e.status == statusPoisoned {
+			poisoned = append(poisoned, e.entry)
+		}
+	}
+	return poisoned
+}
+
+func (s *MemoryStore) update(id int64, update func(e *memoryEntry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].entry.ID == id {
+			update(&s.entries[i])
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox entry %d doesn't exist", id)
+}
+
+// NewMemoryStore creates a new [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
@@ -0,0 +1,176 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// Aggregate is implemented by notifications that belong to an aggregate, like an order or a user.
+	// The [Relay] publishes the notifications of an aggregate in the order they were stored.
+	Aggregate interface {
+		AggregateKey() string
+	}
+
+	// Entry is a notification that is stored in the outbox.
+	Entry struct {
+		// ID is set by the [Store], it increases in the order the entries are added.
+		ID int64
+		// Type is the name the notification type is registered with, see [WithNotification].
+		Type string
+		// Payload is the notification encoded as JSON.
+		Payload  []byte
+		Metadata mediator.Metadata
+		// AggregateKey is the key of [Aggregate] notifications, it is empty for other notifications.
+		AggregateKey string
+		// Attempts is the amount of times publishing the entry failed.
+		Attempts int
+		// RetryAt is the time after which a failed entry is retried.
+		RetryAt time.Time
+		// LastError is the error of the last failed attempt.
+		LastError string
+		CreatedAt time.Time
+	}
+
+	// Store stores the entries of the [Outbox].
+	// It has to be safe for concurrent use, but only a single [Relay] should read from it.
+	Store interface {
+		// Add stores a new entry, the context is the context that the notification was published with.
+		Add(ctx context.Context, entry Entry) error
+		// Pending returns up to limit entries that weren't dispatched or poisoned and are due at now, ordered by their ID.
+		// An entry is due when its RetryAt isn't after now,
+		// and no earlier entry of the same [Aggregate] is waiting for its retry.
+		// Entries that wait for a retry shouldn't take up the limit, so they don't hold back the other entries.
+		Pending(ctx context.Context, now time.Time, limit int) ([]Entry, error)
+		// MarkDispatched marks the entry as published.
+		MarkDispatched(ctx context.Context, id int64) error
+		// MarkFailed records a failed attempt to publish the entry.
+		MarkFailed(ctx context.Context, id int64, attempts int, retryAt time.Time, reason string) error
+		// MarkPoisoned marks the entry as poisoned, it isn't retried anymore.
+		MarkPoisoned(ctx context.Context, id int64, attempts int, reason string) error
+	}
+
+	// Outbox is a [mediator.Outbox] that stores notifications as [Entry] values in a [Store].
+	//
+	// Only the notification types that are registered with [WithNotification] can be stored,
+	// they are encoded as JSON so only their exported fields are stored.
+	Outbox struct {
+		store Store
+		now   func() time.Time
+		// names are the registered names of the notification types
+		names map[reflect.Type]string
+		// publishers decode and publish the entries per registered name
+		publishers map[string]func(ctx context.Context, p mediator.Publisher, entry Entry) error
+	}
+
+	// Option defines the method to customize [New].
+	Option  func(*options)
+	options struct {
+		now        func() time.Time
+		names      map[reflect.Type]string
+		publishers map[string]func(ctx context.Context, p mediator.Publisher, entry Entry) error
+	}
+
+	// permanentError is an error that won't go away when the entry is retried.
+	permanentError struct {
+		err error
+	}
+)
+
+// WithNotification registers the notification type T under the name, so it can be stored in the [Outbox].
+// The name is stored with the entries, it should stay the same when the type is renamed.
+// T has to be a concrete type.
+func WithNotification[T any](name string) Option {
+	return func(o *options) {
+		o.names[reflect.TypeFor[T]()] = name
+		o.publishers[name] = func(ctx context.Context, p mediator.Publisher, entry Entry) error {
+			var notification T
+			if err := json.Unmarshal(entry.Payload, &notification); err != nil {
+				return permanentError{fmt.Errorf("failed to decode %s: %w", name, err)}
+			}
+			return mediator.Publish(ctx, p, notification,
+				mediator.WithOutbox(nil),
+				mediator.WithPublishMetadata(entry.Metadata))
+		}
+	}
+}
+
+// WithNow replaces the function that returns the current time, this is useful in tests.
+func WithNow(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Store implements [mediator.Outbox].
+func (o *Outbox) Store(ctx context.Context, notification any, md mediator.Metadata) error {
+	name, ok := o.names[reflect.TypeOf(notification)]
+	if !ok {
+		return fmt.Errorf("can't store notification %T in the outbox, it isn't registered", notification)
+	}
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	now := o.now()
+	entry := Entry{
+		Type:      name,
+		Payload:   payload,
+		Metadata:  md,
+		RetryAt:   now,
+		CreatedAt: now,
+	}
+	if a, ok := notification.(Aggregate); ok {
+		entry.AggregateKey = a.AggregateKey()
+	}
+	return o.store.Add(ctx, entry)
+}
+
+// publish decodes the entry and publishes it through the [mediator.Publisher].
+func (o *Outbox) publish(ctx context.Context, p mediator.Publisher, entry Entry) error {
+	publish, ok := o.publishers[entry.Type]
+	if !ok {
+		return permanentError{fmt.Errorf("notification type %s isn't registered", entry.Type)}
+	}
+	return publish(ctx, p, entry)
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// isPermanent reports if retrying won't fix the error.
+func isPermanent(err error) bool {
+	var pErr permanentError
+	return errors.As(err, &pErr)
+}
+
+// New creates a new [Outbox] that stores the notifications in the [Store].
+func New(store Store, opt ...Option) *Outbox {
+	// default options
+	opts := &options{
+		now:        time.Now,
+		names:      make(map[reflect.Type]string),
+		publishers: make(map[string]func(ctx context.Context, p mediator.Publisher, entry Entry) error),
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Outbox{
+		store:      store,
+		now:        opts.now,
+		names:      opts.names,
+		publishers: opts.publishers,
+	}
+}
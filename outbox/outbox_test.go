@@ -0,0 +1,111 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/outbox"
+)
+
+type (
+	orderPlaced struct {
+		OrderID string `json:"order_id"`
+		Item    string `json:"item"`
+	}
+
+	newsletterSent struct {
+		Subject string `json:"subject"`
+	}
+
+	// placeOrder is a command that publishes orderPlaced.
+	placeOrder struct {
+		orderID string
+	}
+)
+
+func (e orderPlaced) AggregateKey() string {
+	return e.OrderID
+}
+
+func (c placeOrder) Handle(ctx context.Context, _ *slog.Logger) error {
+	return mediator.Publish(ctx, mediator.SenderFromContext(ctx).(mediator.Publisher), orderPlaced{OrderID: c.orderID, Item: "gopher plush"})
+}
+
+func TestOutbox_Store(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := outbox.NewMemoryStore()
+	m := mediator.New(mediator.WithPublishOutbox(outbox.New(store,
+		outbox.WithNotification[orderPlaced]("order.placed"),
+		outbox.WithNow(func() time.Time { return now }),
+	)))
+
+	require.NoError(t, mediator.Dispatch(ctx, m, placeOrder{orderID: "42"}))
+
+	entries, err := store.Pending(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "order.placed", entry.Type)
+	assert.JSONEq(t, `{"order_id":"42","item":"gopher plush"}`, string(entry.Payload))
+	assert.Equal(t, "42", entry.AggregateKey)
+	assert.Equal(t, now, entry.CreatedAt)
+	assert.Equal(t, now, entry.RetryAt)
+	assert.NotEmpty(t, entry.Metadata.MessageID())
+	assert.NotEmpty(t, entry.Metadata.CausationID(), "the entry should be caused by the command")
+}
+
+func TestOutbox_Unregistered(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New(mediator.WithPublishOutbox(outbox.New(outbox.NewMemoryStore())))
+	err := mediator.Publish(context.Background(), m, newsletterSent{Subject: "Gophers"})
+	require.ErrorContains(t, err, "isn't registered")
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := outbox.NewMemoryStore()
+	for _, item := range []string{"a", "b", "c"} {
+		payload, err := json.Marshal(orderPlaced{Item: item})
+		require.NoError(t, err)
+		require.NoError(t, store.Add(ctx, outbox.Entry{Type: "order.placed", Payload: payload}))
+	}
+
+	entries, err := store.Pending(ctx, time.Now(), 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []int64{1, 2}, []int64{entries[0].ID, entries[1].ID})
+
+	retryAt := time.Now().Add(time.Minute)
+	require.NoError(t, store.MarkDispatched(ctx, 1))
+	require.NoError(t, store.MarkFailed(ctx, 2, 1, retryAt, "handler failed"))
+	require.NoError(t, store.MarkPoisoned(ctx, 3, 5, "can't decode"))
+	require.Error(t, store.MarkDispatched(ctx, 1), "a dispatched entry should be removed")
+
+	entries, err = store.Pending(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "an entry that waits for its retry shouldn't be pending")
+	entries, err = store.Pending(ctx, retryAt, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, retryAt, entries[0].RetryAt)
+	assert.Equal(t, "handler failed", entries[0].LastError)
+
+	poisoned := store.Poisoned()
+	require.Len(t, poisoned, 1)
+	assert.Equal(t, int64(3), poisoned[0].ID)
+	assert.Equal(t, 5, poisoned[0].Attempts)
+}
@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/luukvdm/mediator"
+)
+
+type (
+	// Relay publishes the entries of an [Outbox] through a [mediator.Publisher].
+	//
+	// Entries are published in the order they were stored and marked as dispatched when all handlers succeeded.
+	// A failed entry is retried with exponential backoff, its handlers are called again,
+	// so they should be idempotent.
+	// Later entries of the same [Aggregate] wait until the failed entry is dispatched or poisoned.
+	// An entry is poisoned when it fails the maximum amount of attempts or can't be decoded,
+	// it is reported to the callbacks of [WithPoisonHandler] and isn't retried anymore.
+	Relay struct {
+		outbox    *Outbox
+		publisher mediator.Publisher
+		opts      relayOptions
+	}
+
+	// RelayOption defines the method to customize [NewRelay].
+	RelayOption  func(*relayOptions)
+	relayOptions struct {
+		l            *slog.Logger
+		interval     time.Duration
+		batchSize    int
+		maxAttempts  int
+		initialDelay time.Duration
+		maxDelay     time.Duration
+		onPoison     []func(ctx context.Context, entry Entry, err error)
+	}
+)
+
+// WithRelayLogger sets the logger of the [Relay].
+func WithRelayLogger(l *slog.Logger) RelayOption {
+	return func(o *relayOptions) {
+		o.l = l
+	}
+}
+
+// WithPollInterval sets how often [Relay.Run] reads the outbox.
+// The default is 1 second.
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.interval = interval
+	}
+}
+
+// WithBatchSize sets the maximum amount of entries that are read from the outbox at once.
+// The default is 100.
+func WithBatchSize(size int) RelayOption {
+	return func(o *relayOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithMaxAttempts sets the amount of times an entry is published before it is poisoned.
+// The default is 5.
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(o *relayOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the maximum delay between attempts.
+// The delay doubles after every attempt, until it reaches the maximum.
+// The default is 1 second with a maximum of 5 minutes.
+func WithBackoff(initial, maximum time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.initialDelay = initial
+		o.maxDelay = maximum
+	}
+}
+
+// WithPoisonHandler adds a callback that is called when an entry is poisoned.
+func WithPoisonHandler(onPoison func(ctx context.Context, entry Entry, err error)) RelayOption {
+	return func(o *relayOptions) {
+		o.onPoison = append(o.onPoison, onPoison)
+	}
+}
+
+// Run dispatches the pending entries every poll interval, until the context is done.
+// Errors are logged, Run only returns the error of the context.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.opts.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			r.opts.l.ErrorContext(ctx, "failed to dispatch the outbox", slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publishes the pending entries once and returns the amount of entries that were dispatched.
+// Failed entries aren't returned as an error, the error is about reading and updating the [Store].
+func (r *Relay) Dispatch(ctx context.Context) (int, error) {
+	now := r.outbox.now()
+	entries, err := r.outbox.store.Pending(ctx, now, r.opts.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// blocked holds the aggregates that have an entry that still has to be dispatched
+	blocked := make(map[string]struct{})
+	block := func(entry Entry) {
+		if entry.AggregateKey != "" {
+			blocked[entry.AggregateKey] = struct{}{}
+		}
+	}
+
+	dispatched := 0
+	var errs []error
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, ok := blocked[entry.AggregateKey]; ok {
+			continue
+		}
+		if entry.RetryAt.After(now) {
+			block(entry)
+			continue
+		}
+
+		pubErr := r.outbox.publish(ctx, r.publisher, entry)
+		if pubErr == nil {
+			if err := r.outbox.store.MarkDispatched(ctx, entry.ID); err != nil {
+				errs = append(errs, err)
+				block(entry)
+				continue
+			}
+			dispatched++
+			continue
+		}
+
+		entry.Attempts++
+		entry.LastError = pubErr.Error()
+		if isPermanent(pubErr) || entry.Attempts >= r.opts.maxAttempts {
+			if err := r.outbox.store.MarkPoisoned(ctx, entry.ID, entry.Attempts, entry.LastError); err != nil {
+				errs = append(errs, err)
+				block(entry)
+				continue
+			}
+			r.opts.l.ErrorContext(ctx, "outbox entry "+entry.Type+" is poisoned",
+				slog.Int64("id", entry.ID), slog.Int("attempts", entry.Attempts), slog.Any("error", pubErr))
+			for _, onPoison := range r.opts.onPoison {
+				onPoison(ctx, entry, pubErr)
+			}
+			continue
+		}
+
+		block(entry)
+		entry.RetryAt = now.Add(r.delay(entry.Attempts))
+		r.opts.l.WarnContext(ctx, "failed to publish outbox entry "+entry.Type,
+			slog.Int64("id", entry.ID), slog.Int("attempts", entry.Attempts), slog.Time("retry_at", entry.RetryAt), slog.Any("error", pubErr))
+		if err := r.outbox.store.MarkFailed(ctx, entry.ID, entry.Attempts, entry.RetryAt, entry.LastError); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return dispatched, errors.Join(errs...)
+}
+
+// delay returns the time to wait after the failed attempt.
+func (r *Relay) delay(attempt int) time.Duration {
+	if shift := attempt - 1; shift < 32 && r.opts.initialDelay<<shift < r.opts.maxDelay && r.opts.initialDelay<<shift > 0 {
+		return r.opts.initialDelay << shift
+	}
+	return r.opts.maxDelay
+}
+
+// NewRelay creates a new [Relay] that publishes the entries of the [Outbox] through the [mediator.Publisher].
+func NewRelay(outbox *Outbox, p mediator.Publisher, opt ...RelayOption) *Relay {
+	// default options
+	opts := relayOptions{
+		l:            slog.Default(),
+		interval:     time.Second,
+		batchSize:    100,
+		maxAttempts:  5,
+		initialDelay: time.Second,
+		maxDelay:     5 * time.Minute,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &Relay{
+		outbox:    outbox,
+		publisher: p,
+		opts:      opts,
+	}
+}
@@ -0,0 +1,235 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/outbox"
+)
+
+// relayTest holds a mediator that stores its notifications in an outbox, and a relay for it.
+type relayTest struct {
+	m     mediator.Mediator
+	store *outbox.MemoryStore
+	relay *outbox.Relay
+
+	mu       sync.Mutex
+	now      time.Time
+	received []string
+	// fail makes the handler fail for the items
+	fail map[string]bool
+}
+
+func newRelayTest(t *testing.T, opt ...outbox.RelayOption) *relayTest {
+	t.Helper()
+	rt := &relayTest{
+		store: outbox.NewMemoryStore(),
+		now:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		fail:  make(map[string]bool),
+	}
+	ob := outbox.New(rt.store,
+		outbox.WithNotification[orderPlaced]("order.placed"),
+		outbox.WithNotification[newsletterSent]("newsletter.sent"),
+		outbox.WithNow(rt.clock),
+	)
+	rt.m = mediator.New(mediator.WithPublishOutbox(ob))
+	rt.relay = outbox.NewRelay(ob, rt.m, opt...)
+
+	_, err := mediator.Subscribe(rt.m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, e orderPlaced) error {
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		if rt.fail[e.Item] {
+			return errors.New("warehouse is offline")
+		}
+		rt.received = append(rt.received, e.OrderID+":"+e.Item)
+		return nil
+	}))
+	require.NoError(t, err)
+	return rt
+}
+
+func (rt *relayTest) clock() time.Time {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.now
+}
+
+func (rt *relayTest) advance(d time.Duration) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.now = rt.now.Add(d)
+}
+
+func (rt *relayTest) setFail(item string, fail bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.fail[item] = fail
+}
+
+func (rt *relayTest) publish(t *testing.T, e any) {
+	t.Helper()
+	switch e := e.(type) {
+	case orderPlaced:
+		require.NoError(t, mediator.Publish(context.Background(), rt.m, e))
+	case newsletterSent:
+		require.NoError(t, mediator.Publish(context.Background(), rt.m, e))
+	}
+}
+
+func (rt *relayTest) dispatch(t *testing.T) int {
+	t.Helper()
+	n, err := rt.relay.Dispatch(context.Background())
+	require.NoError(t, err)
+	return n
+}
+
+func (rt *relayTest) Received() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return append([]string(nil), rt.received...)
+}
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t)
+	var correlationIDs []string
+	_, err := mediator.SubscribeAll(rt.m, func(_ context.Context, _ *slog.Logger, msg mediator.NotificationMessage[any]) error {
+		correlationIDs = append(correlationIDs, msg.Metadata().CorrelationID())
+		return nil
+	})
+	require.NoError(t, err)
+
+	ctx := mediator.ContextWithMetadata(context.Background(), mediator.Metadata{mediator.MetadataCorrelationID: "checkout"})
+	require.NoError(t, mediator.Publish(ctx, rt.m, orderPlaced{OrderID: "1", Item: "plush"}))
+	rt.publish(t, orderPlaced{OrderID: "2", Item: "mug"})
+	assert.Empty(t, rt.Received())
+
+	assert.Equal(t, 2, rt.dispatch(t))
+	assert.Equal(t, []string{"1:plush", "2:mug"}, rt.Received())
+	require.Len(t, correlationIDs, 2)
+	assert.Equal(t, "checkout", correlationIDs[0], "the metadata should be published with the entry")
+
+	assert.Zero(t, rt.dispatch(t), "dispatched entries shouldn't be published again")
+}
+
+func TestRelay_Retry(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t, outbox.WithBackoff(time.Second, time.Minute))
+	rt.setFail("plush", true)
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "plush"})
+
+	assert.Zero(t, rt.dispatch(t))
+	entries, err := rt.store.Pending(context.Background(), rt.clock(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "an entry that waits for its retry shouldn't be pending")
+	entries, err = rt.store.Pending(context.Background(), rt.clock().Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, "warehouse is offline", entries[0].LastError)
+
+	rt.setFail("plush", false)
+	assert.Zero(t, rt.dispatch(t), "the entry shouldn't be retried before the backoff passed")
+
+	rt.advance(time.Second)
+	assert.Equal(t, 1, rt.dispatch(t))
+	assert.Equal(t, []string{"1:plush"}, rt.Received())
+}
+
+func TestRelay_AggregateOrdering(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t, outbox.WithBackoff(time.Second, time.Minute))
+	rt.setFail("plush", true)
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "plush"})
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "mug"})
+	rt.publish(t, orderPlaced{OrderID: "2", Item: "sticker"})
+
+	assert.Equal(t, 1, rt.dispatch(t))
+	assert.Equal(t, []string{"2:sticker"}, rt.Received(), "later entries of the failed aggregate should wait")
+
+	rt.setFail("plush", false)
+	rt.advance(time.Second)
+	assert.Equal(t, 2, rt.dispatch(t))
+	assert.Equal(t, []string{"2:sticker", "1:plush", "1:mug"}, rt.Received())
+}
+
+func TestRelay_BackoffDoesNotStarve(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t, outbox.WithBatchSize(2), outbox.WithBackoff(time.Second, time.Minute))
+	rt.setFail("plush", true)
+	rt.setFail("mug", true)
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "plush"})
+	rt.publish(t, orderPlaced{OrderID: "2", Item: "mug"})
+	rt.publish(t, orderPlaced{OrderID: "3", Item: "sticker"})
+
+	assert.Zero(t, rt.dispatch(t))
+	assert.Equal(t, 1, rt.dispatch(t), "entries that wait for their retry shouldn't fill the batch")
+	assert.Equal(t, []string{"3:sticker"}, rt.Received())
+}
+
+func TestRelay_Poison(t *testing.T) {
+	t.Parallel()
+
+	var poisoned []outbox.Entry
+	rt := newRelayTest(t,
+		outbox.WithMaxAttempts(2),
+		outbox.WithBackoff(time.Second, time.Minute),
+		outbox.WithPoisonHandler(func(_ context.Context, entry outbox.Entry, _ error) {
+			poisoned = append(poisoned, entry)
+		}),
+	)
+	rt.setFail("plush", true)
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "plush"})
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "mug"})
+
+	rt.dispatch(t)
+	rt.advance(time.Second)
+	rt.dispatch(t)
+	require.Len(t, poisoned, 1)
+	assert.Equal(t, 2, poisoned[0].Attempts)
+	assert.Equal(t, []string{"1:mug"}, rt.Received(), "a poisoned entry shouldn't block its aggregate")
+	assert.Len(t, rt.store.Poisoned(), 1)
+}
+
+func TestRelay_PoisonUndecodable(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t)
+	require.NoError(t, rt.store.Add(context.Background(), outbox.Entry{Type: "order.placed", Payload: []byte("not json")}))
+	require.NoError(t, rt.store.Add(context.Background(), outbox.Entry{Type: "order.cancelled", Payload: []byte("{}")}))
+
+	assert.Zero(t, rt.dispatch(t))
+	poisoned := rt.store.Poisoned()
+	require.Len(t, poisoned, 2, "entries that can't be decoded should be poisoned right away")
+	assert.Equal(t, 1, poisoned[0].Attempts)
+	assert.Contains(t, poisoned[1].LastError, "isn't registered")
+}
+
+func TestRelay_Run(t *testing.T) {
+	t.Parallel()
+
+	rt := newRelayTest(t, outbox.WithPollInterval(time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- rt.relay.Run(ctx)
+	}()
+
+	rt.publish(t, orderPlaced{OrderID: "1", Item: "plush"})
+	require.Eventually(t, func() bool { return len(rt.Received()) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
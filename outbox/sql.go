@@ -0,0 +1,219 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	// SQLStore is a [Store] that keeps the entries in a table of a [database/sql] database.
+	//
+	// Entries are added with the transaction of the context, see [ContextWithTx] and [WithTxFromContext],
+	// so they are only stored when the transaction of the message that published them commits.
+	// The table has to exist, for example:
+	//
+	//	CREATE TABLE outbox (
+	//		id            BIGSERIAL PRIMARY KEY,
+	//		type          TEXT NOT NULL,
+	//		payload       BYTEA NOT NULL,
+	//		metadata      TEXT NOT NULL,
+	//		aggregate_key TEXT NOT NULL,
+	//		status        TEXT NOT NULL,
+	//		attempts      INTEGER NOT NULL,
+	//		retry_at      TIMESTAMP NOT NULL,
+	//		last_error    TEXT NOT NULL,
+	//		created_at    TIMESTAMP NOT NULL
+	//	);
+	//	CREATE INDEX outbox_pending ON outbox (status, id);
+	//	CREATE INDEX outbox_aggregate ON outbox (aggregate_key, status, id);
+	SQLStore struct {
+		db      *sql.DB
+		tx      func(ctx context.Context) *sql.Tx
+		queries sqlQueries
+	}
+
+	// sqlQueries are the queries with the table name and the placeholders of the database.
+	sqlQueries struct {
+		add        string
+		pending    string
+		dispatched string
+		failed     string
+		poisoned   string
+	}
+
+	// SQLOption defines the method to customize [NewSQLStore].
+	SQLOption  func(*sqlOptions)
+	sqlOptions struct {
+		table       string
+		placeholder func(n int) string
+		tx          func(ctx context.Context) *sql.Tx
+	}
+
+	// execer is implemented by [sql.DB] and [sql.Tx].
+	execer interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	}
+
+	txKey struct{}
+)
+
+// ContextWithTx returns a copy of the context that holds the transaction, the [SQLStore] adds entries with it.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction of [ContextWithTx], or nil if there is none.
+func TxFromContext(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txKey{}).(*sql.Tx)
+	return tx
+}
+
+// WithTable sets the name of the table.
+// The default is outbox.
+func WithTable(table string) SQLOption {
+	return func(o *sqlOptions) {
+		o.table = table
+	}
+}
+
+// WithPlaceholder sets the function that returns the placeholder of the nth argument, starting at 1.
+// The default returns ?, databases like PostgreSQL need numbered placeholders:
+//
+//	outbox.WithPlaceholder(func(n int) string { return "$" + strconv.Itoa(n) })
+func WithPlaceholder(placeholder func(n int) string) SQLOption {
+	return func(o *sqlOptions) {
+		o.placeholder = placeholder
+	}
+}
+
+// WithTxFromContext sets the function that returns the transaction of the context,
+// so a transaction that is started by another library can be used.
+// The database is used when the function returns nil.
+// The default is [TxFromContext].
+func WithTxFromContext(tx func(ctx context.Context) *sql.Tx) SQLOption {
+	return func(o *sqlOptions) {
+		o.tx = tx
+	}
+}
+
+// Add implements [Store].
+func (s *SQLStore) Add(ctx context.Context, entry Entry) error {
+	md, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode the metadata of %s: %w", entry.Type, err)
+	}
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.add,
+		entry.Type, entry.Payload, string(md), entry.AggregateKey, string(statusPending),
+		entry.Attempts, entry.RetryAt, entry.LastError, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to the outbox: %w", entry.Type, err)
+	}
+	return nil
+}
+
+// Pending implements [Store].
+func (s *SQLStore) Pending(ctx context.Context, now time.Time, limit int) ([]Entry, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, s.queries.pending,
+		string(statusPending), now, string(statusPending), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var (
+			entry Entry
+			md    string
+		)
+		if err := rows.Scan(&entry.ID, &entry.Type, &entry.Payload, &md, &entry.AggregateKey,
+			&entry.Attempts, &entry.RetryAt, &entry.LastError, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read the outbox: %w", err)
+		}
+		if err := json.Unmarshal([]byte(md), &entry.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode the metadata of outbox entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the outbox: %w", err)
+	}
+	return entries, nil
+}
+
+// MarkDispatched implements [Store].
+func (s *SQLStore) MarkDispatched(ctx context.Context, id int64) error {
+	return s.update(ctx, id, s.queries.dispatched, string(statusDispatched), id)
+}
+
+// MarkFailed implements [Store].
+func (s *SQLStore) MarkFailed(ctx context.Context, id int64, attempts int, retryAt time.Time, reason string) error {
+	return s.update(ctx, id, s.queries.failed, attempts, retryAt, reason, id)
+}
+
+// MarkPoisoned implements [Store].
+func (s *SQLStore) MarkPoisoned(ctx context.Context, id int64, attempts int, reason string) error {
+	return s.update(ctx, id, s.queries.poisoned, string(statusPoisoned), attempts, reason, id)
+}
+
+func (s *SQLStore) update(ctx context.Context, id int64, query string, args ...any) error {
+	if _, err := s.conn(ctx).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update outbox entry %d: %w", id, err)
+	}
+	return nil
+}
+
+// conn returns the transaction of the context, or the database if there is none.
+func (s *SQLStore) conn(ctx context.Context) execer {
+	if tx := s.tx(ctx); tx != nil {
+		return tx
+	}
+	return s.db
+}
+
+// NewSQLStore creates a new [SQLStore] that keeps the entries in the database.
+func NewSQLStore(db *sql.DB, opt ...SQLOption) *SQLStore {
+	// default options
+	opts := &sqlOptions{
+		table:       "outbox",
+		placeholder: func(int) string { return "?" },
+		tx:          TxFromContext,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	rebind := func(query string) string {
+		var b strings.Builder
+		n := 0
+		for _, r := range strings.ReplaceAll(query, "{table}", opts.table) {
+			if r != '?' {
+				b.WriteRune(r)
+				continue
+			}
+			n++
+			b.WriteString(opts.placeholder(n))
+		}
+		return b.String()
+	}
+	return &SQLStore{
+		db: db,
+		tx: opts.tx,
+		queries: sqlQueries{
+			add: rebind("INSERT INTO {table} (type, payload, metadata, aggregate_key, status, attempts, retry_at, last_error, created_at) " +
+				"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+			pending: rebind("SELECT id, type, payload, metadata, aggregate_key, attempts, retry_at, last_error, created_at " +
+				"FROM {table} e WHERE status = ? AND retry_at <= ? AND NOT EXISTS (" +
+				"SELECT 1 FROM {table} w WHERE w.aggregate_key = e.aggregate_key AND e.aggregate_key <> '' " +
+				"AND w.status = ? AND w.id < e.id AND w.retry_at > ?) " +
+				"ORDER BY id LIMIT ?"),
+			dispatched: rebind("UPDATE {table} SET status = ? WHERE id = ?"),
+			failed:     rebind("UPDATE {table} SET attempts = ?, retry_at = ?, last_error = ? WHERE id = ?"),
+			poisoned:   rebind("UPDATE {table} SET status = ?, attempts = ?, last_error = ? WHERE id = ?"),
+		},
+	}
+}
@@ -0,0 +1,237 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+	"github.com/luukvdm/mediator/outbox"
+)
+
+type (
+	// fakeDriver records the statements that are executed and returns the configured rows for queries.
+	fakeDriver struct {
+		mu         sync.Mutex
+		statements []statement
+		rows       [][]driver.Value
+	}
+
+	statement struct {
+		query string
+		args  []any
+		inTx  bool
+	}
+
+	fakeConn struct {
+		d    *fakeDriver
+		inTx bool
+	}
+
+	fakeTx struct {
+		c *fakeConn
+	}
+
+	fakeRows struct {
+		rows [][]driver.Value
+	}
+)
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+func (d *fakeDriver) record(query string, args []driver.NamedValue, inTx bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := statement{query: query, inTx: inTx}
+	for _, a := range args {
+		s.args = append(s.args, a.Value)
+	}
+	d.statements = append(d.statements, s)
+}
+
+func (d *fakeDriver) last() statement {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.statements[len(d.statements)-1]
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements aren't supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return fakeTx{c: c}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query, args, c.inTx)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(query, args, c.inTx)
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	return &fakeRows{rows: c.d.rows}, nil
+}
+
+func (tx fakeTx) Commit() error {
+	tx.c.inTx = false
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.c.inTx = false
+	return nil
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "type", "payload", "metadata", "aggregate_key", "attempts", "retry_at", "last_error", "created_at"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func TestSQLStore_Add(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := mediator.New(mediator.WithPublishOutbox(outbox.New(outbox.NewSQLStore(db),
+		outbox.WithNotification[orderPlaced]("order.placed"),
+		outbox.WithNow(func() time.Time { return now }),
+	)))
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	ctx = mediator.ContextWithMetadata(ctx, mediator.Metadata{mediator.MetadataMessageID: "parent", mediator.MetadataCorrelationID: "checkout"})
+	require.NoError(t, mediator.Publish(outbox.ContextWithTx(ctx, tx), m, orderPlaced{OrderID: "42", Item: "plush"}))
+	require.NoError(t, tx.Commit())
+
+	s := d.last()
+	assert.True(t, s.inTx, "the entry should be added in the transaction of the context")
+	assert.Equal(t, "INSERT INTO outbox (type, payload, metadata, aggregate_key, status, attempts, retry_at, last_error, created_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", s.query)
+	require.Len(t, s.args, 9)
+	assert.Equal(t, "order.placed", s.args[0])
+	assert.JSONEq(t, `{"order_id":"42","item":"plush"}`, string(s.args[1].([]byte)))
+	assert.Contains(t, s.args[2], `"correlation_id":"checkout"`)
+	assert.Contains(t, s.args[2], `"causation_id":"parent"`)
+	assert.Equal(t, []any{"42", "pending", int64(0), now, "", now}, s.args[3:])
+}
+
+func TestSQLStore_Pending(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	store := outbox.NewSQLStore(db)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.rows = [][]driver.Value{
+		{int64(1), "order.placed", []byte(`{"order_id":"42"}`), `{"message_id":"a"}`, "42", int64(0), now, "", now},
+		{int64(2), "order.placed", []byte(`{"order_id":"43"}`), `{"message_id":"b"}`, "43", int64(2), now.Add(time.Minute), "warehouse is offline", now},
+	}
+
+	entries, err := store.Pending(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []outbox.Entry{
+		{ID: 1, Type: "order.placed", Payload: []byte(`{"order_id":"42"}`), Metadata: mediator.Metadata{mediator.MetadataMessageID: "a"},
+			AggregateKey: "42", RetryAt: now, CreatedAt: now},
+		{ID: 2, Type: "order.placed", Payload: []byte(`{"order_id":"43"}`), Metadata: mediator.Metadata{mediator.MetadataMessageID: "b"},
+			AggregateKey: "43", Attempts: 2, RetryAt: now.Add(time.Minute), LastError: "warehouse is offline", CreatedAt: now},
+	}, entries)
+
+	s := d.last()
+	assert.Equal(t, "SELECT id, type, payload, metadata, aggregate_key, attempts, retry_at, last_error, created_at "+
+		"FROM outbox e WHERE status = ? AND retry_at <= ? AND NOT EXISTS ("+
+		"SELECT 1 FROM outbox w WHERE w.aggregate_key = e.aggregate_key AND e.aggregate_key <> '' "+
+		"AND w.status = ? AND w.id < e.id AND w.retry_at > ?) "+
+		"ORDER BY id LIMIT ?", s.query)
+	assert.Equal(t, []any{"pending", now.Add(time.Minute), "pending", now.Add(time.Minute), int64(10)}, s.args)
+	assert.False(t, s.inTx)
+}
+
+func TestSQLStore_Mark(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	store := outbox.NewSQLStore(db,
+		outbox.WithTable("order_outbox"),
+		outbox.WithPlaceholder(func(n int) string { return "$" + strconv.Itoa(n) }),
+	)
+
+	require.NoError(t, store.MarkDispatched(ctx, 1))
+	assert.Equal(t, statement{query: "UPDATE order_outbox SET status = $1 WHERE id = $2", args: []any{"dispatched", int64(1)}}, d.last())
+
+	retryAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.MarkFailed(ctx, 2, 3, retryAt, "warehouse is offline"))
+	assert.Equal(t, statement{
+		query: "UPDATE order_outbox SET attempts = $1, retry_at = $2, last_error = $3 WHERE id = $4",
+		args:  []any{int64(3), retryAt, "warehouse is offline", int64(2)},
+	}, d.last())
+
+	require.NoError(t, store.MarkPoisoned(ctx, 3, 5, "can't decode"))
+	assert.Equal(t, statement{
+		query: "UPDATE order_outbox SET status = $1, attempts = $2, last_error = $3 WHERE id = $4",
+		args:  []any{"poisoned", int64(5), "can't decode", int64(3)},
+	}, d.last())
+}
+
+func TestSQLStore_TxFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	store := outbox.NewSQLStore(db, outbox.WithTxFromContext(func(context.Context) *sql.Tx { return tx }))
+	require.NoError(t, store.Add(ctx, outbox.Entry{Type: "order.placed", Payload: []byte("{}")}))
+	assert.True(t, d.last().inTx, "the transaction of the function should be used")
+}
@@ -0,0 +1,60 @@
+package mediator_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+// recordingOutbox stores the notifications in a slice.
+type recordingOutbox struct {
+	stored   []any
+	metadata []mediator.Metadata
+}
+
+func (o *recordingOutbox) Store(_ context.Context, notification any, md mediator.Metadata) error {
+	o.stored = append(o.stored, notification)
+	o.metadata = append(o.metadata, md)
+	return nil
+}
+
+func TestPublish_Outbox(t *testing.T) {
+	t.Parallel()
+
+	ctx := mediator.ContextWithMetadata(context.Background(), mediator.Metadata{mediator.MetadataCorrelationID: "flow"})
+	outbox := &recordingOutbox{}
+	m := mediator.New(mediator.WithPublishOutbox(outbox))
+
+	published := 0
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ GopherCreatedEvent) error {
+		published++
+		return nil
+	}))
+	require.NoError(t, err)
+
+	event := GopherCreatedEvent{gopher: Gopher{Name: "Gus"}}
+	require.NoError(t, mediator.Publish(ctx, m, event))
+	assert.Zero(t, published, "the notification should be stored instead of published")
+	assert.Equal(t, []any{event}, outbox.stored)
+	require.Len(t, outbox.metadata, 1)
+	assert.Equal(t, "flow", outbox.metadata[0].CorrelationID())
+	assert.NotEmpty(t, outbox.metadata[0].MessageID())
+
+	require.NoError(t, mediator.Publish(ctx, m, event, mediator.WithOutbox(nil)))
+	assert.Equal(t, 1, published, "the outbox should be skipped when it is set to nil")
+	assert.Len(t, outbox.stored, 1)
+}
+
+func TestPublish_OutboxOption(t *testing.T) {
+	t.Parallel()
+
+	outbox := &recordingOutbox{}
+	m := mediator.New()
+	require.NoError(t, mediator.Publish(context.Background(), m, GopherCreatedEvent{}, mediator.WithOutbox(outbox)))
+	assert.Len(t, outbox.stored, 1, "notifications without subscribers should be stored as well")
+}
@@ -2,7 +2,9 @@ package mediator
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"reflect"
 )
 
 type (
@@ -17,6 +19,13 @@ type (
 	// Behavior is a middleware for the [Mediator].
 	// Behaviors are wrapped around the handling of a [Notification] or [Request].
 	// And executed by the [Pipeline].
+	//
+	// The default [Pipeline] calls the Handler function once, when the [Mediator] is created.
+	// The returned [Handler] is reused for every message,
+	// so a behavior should pass the [Message] it receives on to the next [Handler].
+	// A behavior that wraps the message should embed it, or implement an Unwrap() Message method,
+	// so the wrapped message is handled at the end of the chain.
+	// When a behavior replaces the message with an unknown message type, the message that was sent is handled instead.
 	Behavior interface {
 		Handler(next Handler) Handler
 	}
@@ -28,8 +37,17 @@ type (
 
 	// pipeline is the default [Pipeline] implementation.
 	// It simply chains behaviors.
+	//
+	// The chain is compiled once when the pipeline is created.
+	// The final handler of the chain gets the handler of the message from the message itself.
 	pipeline struct {
 		behaviors []Behavior
+		compiled  Handler
+	}
+
+	// compiledPipeline is implemented by pipelines that build their chain once.
+	compiledPipeline interface {
+		handler() Handler
 	}
 )
 
@@ -40,16 +58,90 @@ func (f HandlerFunc) Handle(ctx context.Context, l *slog.Logger, msg Message) (a
 
 // Then creates a handler chain from the [Pipeline].
 // [Handler] h is the final piece of the chain and the message being handled.
+//
+// The response of every behavior is checked, so a behavior that returns a response of the wrong type
+// results in a [ResponseTypeError] that names the behavior.
 func (c pipeline) Then(hf HandlerFunc) Handler {
 	var h Handler = hf
 	for i := range c.behaviors {
 		next := c.behaviors[len(c.behaviors)-1-i]
-		h = next.Handler(h)
+		h = &checkedHandler{
+			behavior: fmt.Sprintf("%T", next),
+			next:     next.Handler(h),
+		}
 	}
 	return h
 }
 
+func (c pipeline) handler() Handler {
+	return c.compiled
+}
+
 // newPipeline creates a new pipeline for the given [Behavior] slice.
 func newPipeline(behaviors ...Behavior) Pipeline {
-	return pipeline{behaviors: append(([]Behavior)(nil), behaviors...)}
+	c := pipeline{behaviors: append(([]Behavior)(nil), behaviors...)}
+	c.compiled = c.Then(handleMessage)
+	return c
+}
+
+// nestedPipeline chains behaviors after another [Pipeline].
+type nestedPipeline struct {
+	outer Pipeline
+	inner pipeline
+}
+
+func (n nestedPipeline) Then(hf HandlerFunc) Handler {
+	return n.outer.Then(n.inner.Then(hf).Handle)
+}
+
+// pipelineHandler returns the handler chain of the [Pipeline] that ends with handling the message.
+// The chain of the default pipeline is compiled once, other implementations build their chain for every call.
+func pipelineHandler(pl Pipeline) Handler {
+	if c, ok := pl.(compiledPipeline); ok {
+		return c.handler()
+	}
+	return pl.Then(handleMessage)
+}
+
+// handleMessage is the final handler of every chain.
+// It calls the handler that the message carries, messages that were wrapped by a behavior are unwrapped first.
+func handleMessage(ctx context.Context, l *slog.Logger, msg Message) (any, error) {
+	if t, ok := msg.(terminalMessage); ok {
+		return t.handleTerminal(ctx, l)
+	}
+	for inner := unwrapMessage(msg); inner != nil; inner = unwrapMessage(inner) {
+		if t, ok := inner.(terminalMessage); ok {
+			return t.handleTerminal(ctx, l)
+		}
+	}
+	// the message was replaced, the message that was sent is handled instead
+	if t, ok := scopeFromContext(ctx).message.(terminalMessage); ok {
+		return t.handleTerminal(ctx, l)
+	}
+	return nil, fmt.Errorf("message %s can't be handled, a behavior replaced it with an unknown message type %T", msg.String(), msg)
+}
+
+// unwrapMessage returns the message that msg wraps, with its Unwrap method or as an embedded [Message].
+// It returns nil if msg doesn't wrap a message.
+func unwrapMessage(msg Message) Message {
+	if u, ok := msg.(interface{ Unwrap() Message }); ok {
+		return u.Unwrap()
+	}
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := range v.NumField() {
+		if f := v.Type().Field(i); f.Anonymous && f.Type == reflect.TypeFor[Message]() {
+			inner, _ := v.Field(i).Interface().(Message)
+			return inner
+		}
+	}
+	return nil
 }
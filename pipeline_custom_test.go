@@ -1,7 +1,6 @@
 package mediator_test
 
 import (
-	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,12 +25,12 @@ func (c *customPipeline) Then(hf mediator.HandlerFunc) mediator.Handler {
 func TestPipeline_RequestCustom(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	pipeline := &customPipeline{}
 	m := mediator.New(mediator.WithRequestPipeline(pipeline))
 
 	req := mocks.NewMockRequest[string](t)
-	req.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger")).Once().Return("test-123", nil)
+	req.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger")).Once().Return("test-123", nil)
 
 	_, err := mediator.Send[string](ctx, m, req)
 	require.NoError(t, err)
@@ -41,16 +40,17 @@ func TestPipeline_RequestCustom(t *testing.T) {
 func TestPipeline_NotificationCustom(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	pipeline := &customPipeline{}
 	m := mediator.New(mediator.WithNotificationPipeline(pipeline))
 
 	event := "some-event"
 	handler := mocks.NewMockNotificationHandler[string](t)
-	handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).Once().Return(nil)
-	require.NoError(t, mediator.Subscribe[string](m, handler))
+	handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).Once().Return(nil)
+	_, err := mediator.Subscribe[string](m, handler)
+	require.NoError(t, err)
 
-	err := mediator.Publish(ctx, m, event)
+	err = mediator.Publish(ctx, m, event)
 	require.NoError(t, err)
 	assert.True(t, pipeline.isCalled, "custom pipeline is not used")
 }
@@ -3,6 +3,7 @@ package mediator_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"reflect"
 	"testing"
@@ -51,7 +52,7 @@ func TestChain_ResultPassesThroughBehavior(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		ctx := context.Background()
+		ctx := callerContext()
 
 		var next mediator.Handler
 		b := mocks.NewMockBehavior(t)
@@ -69,7 +70,7 @@ func TestChain_ResultPassesThroughBehavior(t *testing.T) {
 		m := mediator.New(mediator.WithRequestBehaviors(b))
 
 		req := mocks.NewMockRequest[string](t)
-		req.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger")).Return(c.result, c.err)
+		req.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger")).Return(c.result, c.err)
 
 		res, err := mediator.Send[string](ctx, m, req)
 		assert.Equal(t, c.result, res)
@@ -80,7 +81,7 @@ func TestChain_ResultPassesThroughBehavior(t *testing.T) {
 func TestChain_BehaviorOrder(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 
 	var counter int
 	b1 := &testBehavior{
@@ -101,9 +102,187 @@ func TestChain_BehaviorOrder(t *testing.T) {
 	m := mediator.New(mediator.WithRequestBehaviors(b1, b2))
 
 	req := mocks.NewMockRequest[string](t)
-	req.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger")).Return("test-123", nil)
+	req.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger")).Return("test-123", nil)
 
 	// use SendAnon to trigger the behavior chain
 	_, err := mediator.Send[string](ctx, m, req)
 	require.NoError(t, err)
 }
+
+type noopBehavior struct{}
+
+func (noopBehavior) Handler(next mediator.Handler) mediator.Handler {
+	return mediator.HandlerFunc(func(ctx context.Context, l *slog.Logger, msg mediator.Message) (any, error) {
+		return next.Handle(ctx, l, msg)
+	})
+}
+
+// rebuildPipeline builds the behavior chain on every call, like the default pipeline used to do.
+type rebuildPipeline struct {
+	behaviors []mediator.Behavior
+}
+
+func (c rebuildPipeline) Then(hf mediator.HandlerFunc) mediator.Handler {
+	var h mediator.Handler = hf
+	for i := range c.behaviors {
+		h = c.behaviors[len(c.behaviors)-1-i].Handler(h)
+	}
+	return h
+}
+
+type pingRequest struct{}
+
+func (pingRequest) Handle(_ context.Context, _ *slog.Logger) (*Gopher, error) {
+	return nil, nil
+}
+
+func noopBehaviors(n int) []mediator.Behavior {
+	behaviors := make([]mediator.Behavior, n)
+	for i := range behaviors {
+		behaviors[i] = noopBehavior{}
+	}
+	return behaviors
+}
+
+func TestPipeline_CompiledOnce(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var builds int
+	b := mocks.NewMockBehavior(t)
+	b.EXPECT().Handler(mock.Anything).RunAndReturn(func(next mediator.Handler) mediator.Handler {
+		builds++
+		return next
+	})
+	m := mediator.New(mediator.WithRequestBehaviors(b))
+
+	for range 5 {
+		_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, builds, "the behavior chain should only be built once")
+}
+
+type (
+	// embeddedMessage wraps a message by embedding it.
+	embeddedMessage struct {
+		mediator.Message
+	}
+
+	// unwrappableMessage wraps a message and returns it with Unwrap.
+	unwrappableMessage struct {
+		msg mediator.Message
+	}
+)
+
+func (m unwrappableMessage) GetInner() any               { return m.msg.GetInner() }
+func (m unwrappableMessage) String() string              { return m.msg.String() }
+func (m unwrappableMessage) Metadata() mediator.Metadata { return m.msg.Metadata() }
+func (m unwrappableMessage) Type() mediator.MessageType  { return m.msg.Type() }
+func (m unwrappableMessage) Unwrap() mediator.Message    { return m.msg }
+
+func TestPipeline_WrappedMessage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wrappers := map[string]func(msg mediator.Message) mediator.Message{
+		"embedded": func(msg mediator.Message) mediator.Message { return embeddedMessage{msg} },
+		"pointer":  func(msg mediator.Message) mediator.Message { return &embeddedMessage{msg} },
+		"unwrap":   func(msg mediator.Message) mediator.Message { return unwrappableMessage{msg} },
+		"nested":   func(msg mediator.Message) mediator.Message { return unwrappableMessage{embeddedMessage{msg}} },
+	}
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+				return next.Handle(ctx, l, wrap(msg))
+			}}
+			m := mediator.New(mediator.WithRequestBehaviors(b))
+
+			gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+			require.NoError(t, err)
+			assert.Equal(t, "Gus", gopher.Name)
+		})
+	}
+}
+
+// unknownMessage is a message type that the mediator doesn't know how to handle.
+type unknownMessage struct {
+	name string
+	md   mediator.Metadata
+}
+
+func (m unknownMessage) GetInner() any               { return nil }
+func (m unknownMessage) String() string              { return m.name }
+func (m unknownMessage) Metadata() mediator.Metadata { return m.md }
+func (m unknownMessage) Type() mediator.MessageType  { return mediator.TypeRequest }
+
+func TestPipeline_ReplacedMessage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	replace := func(msg mediator.Message) mediator.Message {
+		return unknownMessage{name: msg.String(), md: msg.Metadata()}
+	}
+	b := &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+		return next.Handle(ctx, l, replace(msg))
+	}}
+	m := mediator.New(mediator.WithRequestBehaviors(b), mediator.WithNotificationBehaviors(b))
+
+	gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name, "the request that was sent should be handled")
+
+	var event string
+	subscribeFunc(t, m, func(_ context.Context, _ *slog.Logger, e string) error {
+		event = e
+		return nil
+	})
+	require.NoError(t, mediator.Publish(ctx, m, "gopher-found"))
+	assert.Equal(t, "gopher-found", event, "the notification that was published should be handled")
+}
+
+//nolint:paralleltest // allocations are measured globally, so other tests can't run at the same time
+func TestPipeline_FixedAllocations(t *testing.T) {
+	ctx := context.Background()
+
+	sendAllocs := func(behaviors int) float64 {
+		m := mediator.New(mediator.WithRequestBehaviors(noopBehaviors(behaviors)...))
+		return testing.AllocsPerRun(100, func() {
+			_, _ = mediator.Send[*Gopher](ctx, m, pingRequest{})
+		})
+	}
+
+	base := sendAllocs(0)
+	if !raceEnabled {
+		// the metadata of the message is most of the allocations
+		assert.LessOrEqual(t, base, float64(8))
+	}
+	assert.Equal(t, base, sendAllocs(3), "the amount of allocations shouldn't depend on the amount of behaviors")
+	assert.Equal(t, base, sendAllocs(10), "the amount of allocations shouldn't depend on the amount of behaviors")
+}
+
+func BenchmarkSend(b *testing.B) {
+	ctx := context.Background()
+
+	for _, n := range []int{0, 3, 10} {
+		behaviors := noopBehaviors(n)
+		pipelines := []struct {
+			name string
+			m    mediator.Mediator
+		}{
+			{name: "compiled", m: mediator.New(mediator.WithRequestBehaviors(behaviors...))},
+			{name: "rebuild", m: mediator.New(mediator.WithRequestPipeline(rebuildPipeline{behaviors: behaviors}))},
+		}
+		for _, pl := range pipelines {
+			b.Run(fmt.Sprintf("%s/behaviors=%d", pl.name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					_, _ = mediator.Send[*Gopher](ctx, pl.m, pingRequest{})
+				}
+			})
+		}
+	}
+}
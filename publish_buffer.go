@@ -0,0 +1,74 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// PublishBuffer queues the notifications that are published with a context of [ContextWithPublishBuffer],
+// so they can be published after the work they are about is committed.
+//
+// Publishing a notification in the context only queues it, [Publish] returns nil right away.
+// The buffer is inherited by the messages that are sent in the context, so their notifications are queued as well.
+type PublishBuffer struct {
+	mu        sync.Mutex
+	publishes []func(ctx context.Context) error
+}
+
+// ContextWithPublishBuffer returns a copy of the context with a new [PublishBuffer],
+// notifications that are published in the returned context are queued in the buffer.
+func ContextWithPublishBuffer(ctx context.Context) (context.Context, *PublishBuffer) {
+	buffer := &PublishBuffer{}
+	scope := scopeFromContext(ctx)
+	scope.buffer = buffer
+	return contextWithScope(ctx, scope), buffer
+}
+
+// PublishBufferFromContext returns the [PublishBuffer] of the context, or nil if there is none.
+func PublishBufferFromContext(ctx context.Context) *PublishBuffer {
+	return scopeFromContext(ctx).buffer
+}
+
+// add queues the publish of a notification.
+func (b *PublishBuffer) add(publish func(ctx context.Context) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.publishes = append(b.publishes, publish)
+}
+
+// Flush publishes the queued notifications in the order they were published and empties the buffer.
+// Notifications that are published by the handlers while flushing aren't queued.
+// Every notification is published, the errors of the handlers are joined.
+func (b *PublishBuffer) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	publishes := b.publishes
+	b.publishes = nil
+	b.mu.Unlock()
+
+	scope := scopeFromContext(ctx)
+	scope.buffer = nil
+	ctx = contextWithScope(ctx, scope)
+
+	var errs []error
+	for _, publish := range publishes {
+		if err := publish(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Discard drops the queued notifications without publishing them.
+func (b *PublishBuffer) Discard() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.publishes = nil
+}
+
+// Len returns the amount of queued notifications.
+func (b *PublishBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.publishes)
+}
@@ -0,0 +1,112 @@
+package mediator_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+func TestPublishBuffer(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New()
+	var received []string
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, e GopherCreatedEvent) error {
+		received = append(received, e.gopher.Name)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	ctx, buffer := mediator.ContextWithPublishBuffer(context.Background())
+	assert.Same(t, buffer, mediator.PublishBufferFromContext(ctx))
+
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{gopher: Gopher{Name: "Gus"}}))
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{gopher: Gopher{Name: "Gert"}}))
+	assert.Empty(t, received, "the notifications should be queued")
+	assert.Equal(t, 2, buffer.Len())
+
+	require.NoError(t, buffer.Flush(ctx))
+	assert.Equal(t, []string{"Gus", "Gert"}, received)
+	assert.Zero(t, buffer.Len())
+
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{gopher: Gopher{Name: "Gopher"}}))
+	buffer.Discard()
+	require.NoError(t, buffer.Flush(ctx))
+	assert.Equal(t, []string{"Gus", "Gert"}, received, "discarded notifications shouldn't be published")
+}
+
+func TestPublishBuffer_Skip(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New()
+	var received []string
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, e GopherCreatedEvent) error {
+		received = append(received, e.gopher.Name)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	ctx, buffer := mediator.ContextWithPublishBuffer(context.Background())
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{gopher: Gopher{Name: "Gus"}}, mediator.WithoutPublishBuffer()))
+	assert.Equal(t, []string{"Gus"}, received, "the notification should be published right away")
+	assert.Zero(t, buffer.Len())
+}
+
+func TestPublishBuffer_InheritedBySentMessages(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New()
+	published := 0
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ GopherCreatedEvent) error {
+		published++
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NoError(t, mediator.RegisterHandler(m, mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		gopher := Gopher{Name: req.name}
+		return gopher, mediator.Publish(ctx, m, GopherCreatedEvent{gopher: gopher})
+	})))
+
+	ctx, buffer := mediator.ContextWithPublishBuffer(context.Background())
+	ctx = mediator.ContextWithMetadata(ctx, mediator.Metadata{mediator.MetadataCorrelationID: "flow"})
+	_, err = mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Equal(t, 1, buffer.Len())
+
+	var md mediator.Metadata
+	_, err = mediator.SubscribeAll(m, func(_ context.Context, _ *slog.Logger, msg mediator.NotificationMessage[any]) error {
+		md = msg.Metadata()
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, buffer.Flush(context.Background()))
+	assert.Equal(t, 1, published)
+	assert.Equal(t, "flow", md.CorrelationID(), "the metadata should be inherited from where the notification was published")
+}
+
+func TestPublishBuffer_FlushErrors(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New()
+	errHandler := errors.New("gopher escaped")
+	calls := 0
+	_, err := mediator.Subscribe(m, mediator.NewNotificationHandler(func(ctx context.Context, _ *slog.Logger, _ GopherCreatedEvent) error {
+		calls++
+		assert.Nil(t, mediator.PublishBufferFromContext(ctx), "notifications published while flushing shouldn't be queued")
+		return errHandler
+	}))
+	require.NoError(t, err)
+
+	ctx, buffer := mediator.ContextWithPublishBuffer(context.Background())
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{}))
+	require.NoError(t, mediator.Publish(ctx, m, GopherCreatedEvent{}))
+	require.ErrorIs(t, buffer.Flush(ctx), errHandler)
+	assert.Equal(t, 2, calls, "every notification should be published")
+}
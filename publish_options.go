@@ -6,8 +6,13 @@ type (
 	// PublishOption defines the method to customize [Publisher].
 	PublishOption  func(*publishOptions)
 	publishOptions struct {
-		l              *slog.Logger
-		enableParallel bool
+		l        *slog.Logger
+		strategy PublishStrategy
+		pool     *WorkerPool
+		metadata Metadata
+		outbox   Outbox
+		// skipBuffer ignores the PublishBuffer of the context
+		skipBuffer bool
 	}
 )
 
@@ -19,10 +24,50 @@ func WithPublishLogger(l *slog.Logger) PublishOption {
 }
 
 // WithParallelEnabled when enabled runs notification handlers in parallel.
-// This currently creates a new goroutine for every handler.
+// This creates a new goroutine for every handler, unless the [Mediator] has a [WorkerPool].
 // Can be enabled by default using [WithParallelNotifications].
+//
+// Enabling it is the same as using the [ParallelWaitAll] strategy,
+// disabling it is the same as using the [SequentialRunAll] strategy.
 func WithParallelEnabled(enabled bool) PublishOption {
 	return func(o *publishOptions) {
-		o.enableParallel = enabled
+		if enabled {
+			o.strategy = ParallelWaitAll()
+		} else {
+			o.strategy = SequentialRunAll()
+		}
+	}
+}
+
+// WithStrategy sets the [PublishStrategy] that decides how the notification handlers are called.
+// The default strategy can be set using [WithPublishStrategy].
+func WithStrategy(strategy PublishStrategy) PublishOption {
+	return func(o *publishOptions) {
+		o.strategy = strategy
+	}
+}
+
+// WithPublishMetadata adds [Metadata] to the notification that is published.
+// The given values take precedence over the values that are inherited from the context.
+func WithPublishMetadata(md Metadata) PublishOption {
+	return func(o *publishOptions) {
+		o.metadata = md
+	}
+}
+
+// WithOutbox stores the notification in the [Outbox] instead of publishing it.
+// The default outbox can be set using [WithPublishOutbox], passing nil publishes the notification right away.
+func WithOutbox(outbox Outbox) PublishOption {
+	return func(o *publishOptions) {
+		o.outbox = outbox
+	}
+}
+
+// WithoutPublishBuffer publishes the notification right away, even if the context holds a [PublishBuffer].
+// This is useful for notifications that are about something that happened regardless of the outcome of the work,
+// so they shouldn't be discarded when the work is rolled back.
+func WithoutPublishBuffer() PublishOption {
+	return func(o *publishOptions) {
+		o.skipBuffer = true
 	}
 }
@@ -0,0 +1,169 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+type (
+	// PublishStrategy decides how the handlers of a published [Notification] are called
+	// and how their errors are combined.
+	//
+	// A strategy can be set for every [Publish] call with [WithStrategy],
+	// or as the default of a [Mediator] with [WithPublishStrategy].
+	PublishStrategy interface {
+		Publish(ctx context.Context, l *slog.Logger, handlers NotificationHandlers) error
+	}
+
+	// NotificationHandlers holds the handlers that are subscribed to a published [Notification].
+	// It is passed to a [PublishStrategy].
+	NotificationHandlers interface {
+		// Len returns the amount of handlers.
+		Len() int
+		// Handle passes the [Notification] through the [Pipeline] to the handler at index i.
+		Handle(ctx context.Context, i int) error
+		// Go runs f in the background.
+		// It uses the [WorkerPool] of the [Mediator] if it has one, otherwise f runs in a new goroutine.
+		// Strategies that call handlers in the background should use Go instead of the go statement.
+		//
+		// The error is not nil if f could not be scheduled, in that case f is never called.
+		// [ErrHandlerDropped] is returned if the pool dropped the call.
+		Go(ctx context.Context, f func()) error
+	}
+
+	sequentialStrategy struct {
+		stopOnError bool
+	}
+	parallelStrategy struct {
+		cancelOnError bool
+	}
+	fireAndForgetStrategy struct{}
+)
+
+// SequentialStopOnError returns a [PublishStrategy] that calls the handlers one after the other.
+// It stops at, and returns, the first error.
+// Handlers after the failing handler are not called.
+func SequentialStopOnError() PublishStrategy {
+	return sequentialStrategy{stopOnError: true}
+}
+
+// SequentialRunAll returns a [PublishStrategy] that calls the handlers one after the other.
+// Every handler is called, even when a previous handler failed.
+// The errors of all handlers are joined using [errors.Join].
+//
+// This is the default strategy of a [Mediator].
+func SequentialRunAll() PublishStrategy {
+	return sequentialStrategy{stopOnError: false}
+}
+
+// ParallelWaitAll returns a [PublishStrategy] that calls every handler in a separate goroutine,
+// or on the [WorkerPool] of the [Mediator].
+// It waits for all handlers to finish and joins their errors using [errors.Join].
+func ParallelWaitAll() PublishStrategy {
+	return parallelStrategy{cancelOnError: false}
+}
+
+// ParallelCancelOnError returns a [PublishStrategy] that calls every handler in a separate goroutine,
+// or on the [WorkerPool] of the [Mediator].
+// When a handler fails, the context passed to the other handlers is cancelled.
+// It waits for all handlers to finish and returns the first error.
+func ParallelCancelOnError() PublishStrategy {
+	return parallelStrategy{cancelOnError: true}
+}
+
+// FireAndForget returns a [PublishStrategy] that calls every handler in a separate goroutine without waiting for them.
+// The handlers receive a context that isn't cancelled when the publishing context is.
+// Errors can't be returned to the publisher, so they are logged instead.
+func FireAndForget() PublishStrategy {
+	return fireAndForgetStrategy{}
+}
+
+func (s sequentialStrategy) Publish(ctx context.Context, _ *slog.Logger, handlers NotificationHandlers) error {
+	var errs []error
+
+	for i := range handlers.Len() {
+		if err := handlers.Handle(ctx, i); err != nil {
+			if s.stopOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (s parallelStrategy) Publish(ctx context.Context, l *slog.Logger, handlers NotificationHandlers) error {
+	var cancel context.CancelFunc
+	if s.cancelOnError {
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(handlers.Len())
+
+	var errs []error
+	var errMu sync.Mutex
+	addErr := func(err error) {
+		errMu.Lock()
+		if !s.cancelOnError || len(errs) == 0 {
+			errs = append(errs, err)
+		}
+		errMu.Unlock()
+		if s.cancelOnError {
+			cancel()
+		}
+	}
+
+	for i := range handlers.Len() {
+		err := handlers.Go(ctx, func() {
+			defer wg.Done()
+			if s.cancelOnError && ctx.Err() != nil {
+				// a sibling already failed
+				return
+			}
+			if err := handlers.Handle(ctx, i); err != nil {
+				addErr(err)
+			}
+		})
+		if err != nil {
+			wg.Done()
+			if errors.Is(err, ErrHandlerDropped) {
+				l.WarnContext(ctx, "a notification handler call was dropped", slog.Any("error", err))
+				continue
+			}
+			addErr(err)
+		}
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if s.cancelOnError {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+func (s fireAndForgetStrategy) Publish(ctx context.Context, l *slog.Logger, handlers NotificationHandlers) error {
+	ctx = context.WithoutCancel(ctx)
+	for i := range handlers.Len() {
+		err := handlers.Go(ctx, func() {
+			if err := handlers.Handle(ctx, i); err != nil {
+				l.ErrorContext(ctx, "a notification handler failed", slog.Any("error", err))
+			}
+		})
+		if err != nil {
+			l.ErrorContext(ctx, "a notification handler could not be started", slog.Any("error", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package mediator_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+func subscribeFunc(t *testing.T, p mediator.Publisher, f func(ctx context.Context, l *slog.Logger, event string) error) {
+	t.Helper()
+	_, err := mediator.Subscribe(p, mediator.NewNotificationHandler(f))
+	require.NoError(t, err)
+}
+
+func TestPublishStrategy_SequentialStopOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithPublishStrategy(mediator.SequentialStopOnError()))
+
+	myErr := errors.New("fake error")
+	var calls []int
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls = append(calls, 1)
+		return nil
+	})
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls = append(calls, 2)
+		return myErr
+	})
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls = append(calls, 3)
+		return nil
+	})
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.ErrorIs(t, err, myErr)
+	assert.Equal(t, []int{1, 2}, calls, "handlers after the failing handler shouldn't be called")
+}
+
+func TestPublishStrategy_SequentialRunAll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithPublishStrategy(mediator.SequentialRunAll()))
+
+	err1, err2 := errors.New("error 1"), errors.New("error 2")
+	var calls []int
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls = append(calls, 1)
+		return err1
+	})
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls = append(calls, 2)
+		return err2
+	})
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.ErrorIs(t, err, err1)
+	require.ErrorIs(t, err, err2)
+	assert.Equal(t, []int{1, 2}, calls)
+}
+
+func TestPublishStrategy_ParallelWaitAll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithPublishStrategy(mediator.ParallelWaitAll()))
+
+	handlerCount := 5
+	// every handler waits for the others, so this only finishes when they run in parallel
+	var ready sync.WaitGroup
+	ready.Add(handlerCount)
+	var finished atomic.Int32
+	for range handlerCount {
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			ready.Done()
+			ready.Wait()
+			finished.Add(1)
+			return nil
+		})
+	}
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.NoError(t, err)
+	assert.Equal(t, int32(handlerCount), finished.Load(), "publish should wait for all handlers")
+}
+
+func TestPublishStrategy_ParallelCancelOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithPublishStrategy(mediator.ParallelCancelOnError()))
+
+	myErr := errors.New("fake error")
+	var started sync.WaitGroup
+	started.Add(2)
+	subscribeFunc(t, p, func(ctx context.Context, _ *slog.Logger, _ string) error {
+		started.Done()
+		// wait for the failing sibling to cancel the context
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+		started.Done()
+		started.Wait()
+		return myErr
+	})
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.Equal(t, myErr, err, "only the first error should be returned")
+}
+
+func TestPublishStrategy_FireAndForget(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf safeBuffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+	p := mediator.New(mediator.WithLogger(l), mediator.WithPublishStrategy(mediator.FireAndForget()))
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	subscribeFunc(t, p, func(ctx context.Context, _ *slog.Logger, _ string) error {
+		<-release
+		done <- ctx.Err()
+		return errors.New("fire and forget failure")
+	})
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.NoError(t, err, "publish shouldn't wait for the handlers")
+	cancel()
+	close(release)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err, "the handler context shouldn't be cancelled with the publish context")
+	case <-time.After(time.Second):
+		t.Fatal("the handler was never called")
+	}
+	assert.Eventually(t, func() bool {
+		return bytes.Contains(buf.Bytes(), []byte("fire and forget failure"))
+	}, time.Second, time.Millisecond, "the handler error should be logged")
+}
+
+func TestPublishStrategy_PublishOption(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithPublishStrategy(mediator.SequentialStopOnError()))
+
+	var calls atomic.Int32
+	for range 2 {
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			calls.Add(1)
+			return errors.New("fake error")
+		})
+	}
+
+	err := mediator.Publish(ctx, p, "some-event", mediator.WithStrategy(mediator.SequentialRunAll()))
+	require.Error(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "the publish option should overwrite the default strategy")
+
+	// the publish option shouldn't change the default of the mediator
+	calls.Store(0)
+	err = mediator.Publish(ctx, p, "some-event")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// safeBuffer is a [bytes.Buffer] that can be written to and read from concurrently.
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
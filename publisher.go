@@ -2,9 +2,10 @@ package mediator
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"log/slog"
-	"sync"
+	"reflect"
+	"sync/atomic"
 )
 
 type (
@@ -18,6 +19,31 @@ type (
 		handleFunc func(ctx context.Context, l *slog.Logger, event T) error
 	}
 
+	// notificationHandlers implements [NotificationHandlers] for a published [Notification].
+	notificationHandlers[T any] struct {
+		l       *slog.Logger
+		handler Handler
+		// notification is converted to the interface once, instead of for every handler
+		notification Notification[T]
+		handlers     []NotificationHandler[T]
+		pool         *WorkerPool
+		// metadata is shared by the handlers, a handler copies it when it accesses it
+		metadata Metadata
+		// scope is the scope of the publisher, it is the parent scope of every handler
+		scope messageScope
+		// scopes are allocated together, so calling a handler doesn't allocate
+		scopes []handlerScope[T]
+	}
+
+	// handlerScope holds the context and the message of one handler of a published [Notification].
+	handlerScope[T any] struct {
+		ctx      scopeContext
+		msg      notificationMessage[T]
+		metadata handlerMetadata
+		// used is set when the handler is called, a strategy that calls a handler again gets a new scope
+		used atomic.Bool
+	}
+
 	// Publisher can be used to subscribe and publish notifications.
 	// Publishing and subscribing is done through the [Publish] and [Subscribe] functions that take
 	// Publisher as a parameter.
@@ -28,8 +54,8 @@ type (
 	Publisher interface {
 		getNotificationPipeline() Pipeline
 		getLogger() *slog.Logger
-		getAllNotifiers() map[any][]any
-		newNotifier(key any, notifier any)
+		loadNotifiers() *notifierSnapshot
+		updateNotifiers(key any, update func(current any) any)
 		getDefaultPublishOpts() *publishOptions
 	}
 
@@ -52,12 +78,74 @@ func NewNotificationHandler[T any](handleFunc func(ctx context.Context, l *slog.
 // Subscribe to a [Notification] using [Publisher].
 // When a [Notification] is published, every subscriber triggers the [Pipeline].
 // So every subscriber in for the event makes the [Notification] go through the chain.
-func Subscribe[T any](p Publisher, s NotificationHandler[T]) error {
-	p.newNotifier(key[T]{}, s)
-	return nil
+//
+// The returned [Subscription] can be used to remove the handler from the [Publisher] again.
+func Subscribe[T any](p Publisher, s NotificationHandler[T]) (Subscription, error) {
+	return subscribe(p, s), nil
+}
+
+// SubscribeContext is like [Subscribe], but the handler is unsubscribed automatically when the context is done.
+// This is useful for short-lived subscribers, like a listener that lives as long as a websocket connection.
+//
+// If the context is already done, the handler isn't subscribed and the context error is returned.
+func SubscribeContext[T any](ctx context.Context, p Publisher, s NotificationHandler[T]) (Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sub := subscribe(p, s)
+	sub.bind(ctx)
+	return sub, nil
+}
+
+// SubscribeInterface subscribes a handler to every [Notification] that implements the interface I.
+// The handler is called in addition to the handlers that are subscribed to the exact type with [Subscribe].
+//
+// This allows a handler for an interface like DomainEvent to receive a UserCreated notification
+// that is published with Publish[UserCreated].
+//
+// The handlers for a published type are resolved on the first [Publish] of that type and cached,
+// until the subscribers change.
+// An error is returned if I isn't an interface type.
+func SubscribeInterface[I any](p Publisher, s NotificationHandler[I]) (Subscription, error) {
+	if t := reflect.TypeFor[I](); t.Kind() != reflect.Interface {
+		return nil, fmt.Errorf("can't subscribe to %s, it isn't an interface type", t)
+	}
+	id := addInterfaceNotifier(p, s)
+	return newSubscription(func() {
+		removeInterfaceNotifier(p, id)
+	}), nil
+}
+
+// SubscribeAll subscribes a catch-all handler that receives every [Notification] that is published through the [Publisher],
+// whatever its type. This is useful for things like writing an audit trail or forwarding notifications to other systems.
+//
+// Like other handlers, catch-all handlers are called through the notification [Pipeline].
+// The handler receives the notification wrapped in a [NotificationMessage],
+// so it can filter on the name and type of the notification using [Message.String] and [Message.Type].
+func SubscribeAll(p Publisher, handleFunc func(ctx context.Context, l *slog.Logger, msg NotificationMessage[any]) error) (Subscription, error) {
+	handler := NewNotificationHandler(func(ctx context.Context, l *slog.Logger, notification any) error {
+		return handleFunc(ctx, l, notificationMessage[any]{notification: notification, metadata: MetadataFromContext(ctx)})
+	})
+	id := addInterfaceNotifier(p, handler)
+	return newSubscription(func() {
+		removeInterfaceNotifier(p, id)
+	}), nil
+}
+
+func subscribe[T any](p Publisher, s NotificationHandler[T]) *subscription {
+	id := addNotifier(p, key[T]{}, s)
+	return newSubscription(func() {
+		removeNotifier[T](p, key[T]{}, id)
+	})
 }
 
 // Publish a [Notification] using [Publisher].
+// If the context holds a [PublishBuffer], the notification is queued in it instead.
+// If an [Outbox] is set, the notification is stored in it instead.
+//
+// Publishing a notification without handlers doesn't allocate, and calling its handlers doesn't allocate either.
+// The handlers share the [Metadata] of the notification, a handler only gets its own copy
+// when it, or a [Behavior] in its [Pipeline], accesses the metadata.
 //
 // The [Publisher] interface is implemented by [Mediator].
 func Publish[T Notification[any]](ctx context.Context, p Publisher, notification T, options ...PublishOption) error {
@@ -76,82 +164,74 @@ func PublishWithLogger[T Notification[any]](ctx context.Context, l *slog.Logger,
 }
 
 func publish[T Notification[any]](ctx context.Context, p Publisher, notification T, options ...PublishOption) error {
-	opts := p.getDefaultPublishOpts()
-	// overwrite default options with given options
-	for _, o := range options {
-		o(opts)
+	scope := scopeFromContext(ctx)
+	buffer := scope.buffer
+	handlers := resolveHandlers(p, notification)
+	// skip parsing the options when there is nothing to do, so publishing without handlers doesn't allocate
+	if len(handlers) == 0 && len(options) == 0 && buffer == nil && p.getDefaultPublishOpts().outbox == nil {
+		return nil
 	}
 
-	var handlers []NotificationHandler[T]
-	for _, h := range p.getAllNotifiers()[key[T]{}] {
-		handlers = append(handlers, h.(NotificationHandler[T]))
+	// copy the defaults, so the options don't leak into other publish calls
+	opts := *p.getDefaultPublishOpts()
+	// overwrite default options with given options
+	for _, o := range options {
+		o(&opts)
 	}
-
-	if len(handlers) == 0 {
-		return nil
+	if opts.skipBuffer {
+		buffer = nil
 	}
 
-	pl := p.getNotificationPipeline()
-
-	var err error
-	if opts.enableParallel {
-		err = runHandlersParallel(ctx, opts.l, pl, notification, handlers)
-	} else {
-		err = runHandlersSerial(ctx, opts.l, pl, notification, handlers)
+	if opts.outbox != nil {
+		return opts.outbox.Store(ctx, notification, newMetadata(ctx, opts.metadata))
 	}
-
-	return err
-}
-
-func runHandlersSerial[T Notification[any]](ctx context.Context, l *slog.Logger, pl Pipeline, notification T, handlers []NotificationHandler[T]) error {
-	var errs []error
-
-	for _, h := range handlers {
-		handlerPl := pl.Then(func(ctx context.Context, l *slog.Logger, _ Message) (any, error) {
-			return nil, h.Handle(ctx, l, notification)
+	if buffer != nil {
+		// the metadata is created now, so it inherits from the message that published the notification
+		options = append(options[:len(options):len(options)], WithPublishMetadata(newMetadata(ctx, opts.metadata)))
+		buffer.add(func(ctx context.Context) error {
+			return publish(ctx, p, notification, options...)
 		})
-
-		_, err := handlerPl.Handle(ctx, l, NewNotificationMessage[T](notification))
-		if err != nil {
-			errs = append(errs, err)
-		}
+		return nil
 	}
-
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	if len(handlers) == 0 {
+		return nil
 	}
 
-	return nil
+	return opts.strategy.Publish(ctx, opts.l, notificationHandlers[T]{
+		l:            opts.l,
+		handler:      pipelineHandler(p.getNotificationPipeline()),
+		notification: notification,
+		handlers:     handlers,
+		pool:         opts.pool,
+		metadata:     newMetadata(ctx, opts.metadata),
+		scope:        scope,
+		scopes:       make([]handlerScope[T], len(handlers)),
+	})
 }
 
-func runHandlersParallel[T Notification[any]](ctx context.Context, l *slog.Logger, pl Pipeline, notification T, handlers []NotificationHandler[T]) error {
-	var wg sync.WaitGroup
-	wg.Add(len(handlers))
-
-	var errs []error
-	var errMu sync.Mutex
-
-	for _, h := range handlers {
-		// TODO: could add a goroutine pool option
-		go func() {
-			handlerPl := pl.Then(func(ctx context.Context, l *slog.Logger, _ Message) (any, error) {
-				return nil, h.Handle(ctx, l, notification)
-			})
+func (n notificationHandlers[T]) Len() int {
+	return len(n.handlers)
+}
 
-			_, err := handlerPl.Handle(ctx, l, NewNotificationMessage[T](notification))
-			if err != nil {
-				errMu.Lock()
-				errs = append(errs, err)
-				errMu.Unlock()
-			}
-			wg.Done()
-		}()
+func (n notificationHandlers[T]) Handle(ctx context.Context, i int) error {
+	hs := &n.scopes[i]
+	if hs.used.Swap(true) {
+		// the context of the previous call could still be in use
+		hs = &handlerScope[T]{}
 	}
-	wg.Wait()
+	hs.metadata.shared = n.metadata
+	hs.msg = notificationMessage[T]{notification: n.notification, handler: n.handlers[i], scoped: &hs.metadata}
+	hs.ctx = scopeContext{Context: ctx, scope: n.scope}
+	hs.ctx.scope.metadata, hs.ctx.scope.handler = nil, &hs.metadata
+	hs.ctx.scope.message = &hs.msg
+	_, err := n.handler.Handle(&hs.ctx, n.l, &hs.msg)
+	return err
+}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+func (n notificationHandlers[T]) Go(ctx context.Context, f func()) error {
+	if n.pool == nil {
+		go f()
+		return nil
 	}
-
-	return nil
+	return n.pool.Submit(ctx, f)
 }
@@ -23,23 +23,23 @@ func TestSubscribe(t *testing.T) {
 	p := mediator.New()
 
 	handler := mocks.NewMockNotificationHandler[string](t)
-	err := mediator.Subscribe[string](p, handler)
+	_, err := mediator.Subscribe[string](p, handler)
 	require.NoError(t, err)
 }
 
 func TestSubscribe_Multiple(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	p := mediator.New()
 	myEvent := "test-123"
 
 	for i := 0; i < 5; i++ {
 		handler := mocks.NewMockNotificationHandler[string](t)
-		handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+		handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 			Once().
 			Return(nil)
-		err := mediator.Subscribe[string](p, handler)
+		_, err := mediator.Subscribe[string](p, handler)
 		require.NoError(t, err)
 	}
 
@@ -50,15 +50,15 @@ func TestSubscribe_Multiple(t *testing.T) {
 func TestPublish(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	p := mediator.New()
 	myEvent := "test-123"
 
 	handler := mocks.NewMockNotificationHandler[string](t)
-	handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+	handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 		Once().
 		Return(nil)
-	err := mediator.Subscribe[string](p, handler)
+	_, err := mediator.Subscribe[string](p, handler)
 	require.NoError(t, err)
 
 	err = mediator.Publish(ctx, p, myEvent)
@@ -68,18 +68,18 @@ func TestPublish(t *testing.T) {
 func TestPublish_Errors(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	p := mediator.New()
 
 	myEvent := "test"
 	myErr := errors.New("fake error")
 	handler := mocks.NewMockNotificationHandler[string](t)
-	handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+	handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 		Twice().
 		Return(myErr)
-	err := mediator.Subscribe[string](p, handler)
+	_, err := mediator.Subscribe[string](p, handler)
 	require.NoError(t, err)
-	err = mediator.Subscribe[string](p, handler)
+	_, err = mediator.Subscribe[string](p, handler)
 	require.NoError(t, err)
 
 	err = mediator.Publish(ctx, p, myEvent)
@@ -90,7 +90,7 @@ func TestPublish_Errors(t *testing.T) {
 func TestPublish_Parallel(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	p := mediator.New(mediator.WithParallelNotifications())
 
 	myEvent := "some-event"
@@ -99,13 +99,13 @@ func TestPublish_Parallel(t *testing.T) {
 	wg.Add(handlerCount)
 	for range handlerCount {
 		handler := mocks.NewMockNotificationHandler[string](t)
-		handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+		handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 			Once().
 			Run(func(_ mock.Arguments) {
 				wg.Done()
 			}).
 			Return(nil)
-		err := mediator.Subscribe(p, handler)
+		_, err := mediator.Subscribe(p, handler)
 		require.NoError(t, err)
 	}
 
@@ -118,7 +118,7 @@ func TestPublish_Parallel(t *testing.T) {
 func TestPublish_ParallelErrors(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 	p := mediator.New()
 
 	myEvent := "some-event"
@@ -127,13 +127,13 @@ func TestPublish_ParallelErrors(t *testing.T) {
 	wg.Add(handlerCount)
 	for i := range handlerCount {
 		handler := mocks.NewMockNotificationHandler[string](t)
-		handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+		handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 			Once().
 			Run(func(_ mock.Arguments) {
 				wg.Done()
 			}).
 			Return(fmt.Errorf("handler %d errored out", i))
-		err := mediator.Subscribe(p, handler)
+		_, err := mediator.Subscribe(p, handler)
 		require.NoError(t, err)
 	}
 
@@ -173,8 +173,9 @@ func TestPublish_WithLogger(t *testing.T) {
 			assert.Exactly(t, logger, l)
 			return nil
 		})
-		require.NoError(t, mediator.Subscribe(p, handler))
-		err := mediator.Publish(ctx, p, myEvent, mediator.WithPublishLogger(logger))
+		_, err := mediator.Subscribe(p, handler)
+		require.NoError(t, err)
+		err = mediator.Publish(ctx, p, myEvent, mediator.WithPublishLogger(logger))
 		require.NoError(t, err)
 	})
 
@@ -191,8 +192,9 @@ func TestPublish_WithLogger(t *testing.T) {
 			assert.Exactly(t, logger, l)
 			return nil
 		})
-		require.NoError(t, mediator.Subscribe(p, handler))
-		err := mediator.Publish(ctx, p, myEvent)
+		_, err := mediator.Subscribe(p, handler)
+		require.NoError(t, err)
+		err = mediator.Publish(ctx, p, myEvent)
 		require.NoError(t, err)
 	})
 
@@ -211,7 +213,7 @@ func TestPublish_InlineSubscriber(t *testing.T) {
 		eventHandled = true
 		return nil
 	})
-	err := mediator.Subscribe(p, handler)
+	_, err := mediator.Subscribe(p, handler)
 	require.NoError(t, err)
 
 	err = mediator.Publish(ctx, p, myEvent)
@@ -223,16 +225,16 @@ func TestPublish_InlineSubscriber(t *testing.T) {
 func TestPublish_BehaviorPersistence(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 
 	behav := &testBehavior{}
 	m := mediator.New(mediator.WithNotificationBehaviors(behav))
 	myEvent := "test"
 
 	handler := mocks.NewMockNotificationHandler[string](t)
-	handler.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger"), mock.Anything).
+	handler.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger"), mock.Anything).
 		Return(nil)
-	err := mediator.Subscribe[string](m, handler)
+	_, err := mediator.Subscribe[string](m, handler)
 	require.NoError(t, err)
 
 	rounds := 5
@@ -242,3 +244,235 @@ func TestPublish_BehaviorPersistence(t *testing.T) {
 	}
 	assert.Equal(t, rounds, behav.counter, "publish seems to use copies of the behavior instead of reusing them (or not using them at all)")
 }
+
+//nolint:paralleltest // allocations are measured globally, so other tests can't run at the same time
+func TestPublish_NoHandlersDoesNotAllocate(t *testing.T) {
+	ctx := context.Background()
+	p := mediator.New()
+
+	// handlers for another type shouldn't matter
+	_, err := mediator.Subscribe(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ int) error {
+		return nil
+	}))
+	require.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = mediator.Publish(ctx, p, "some-event")
+	})
+	assert.Zero(t, allocs)
+}
+
+//nolint:paralleltest // allocations are measured globally, so other tests can't run at the same time
+func TestPublish_HandlersDoNotAllocate(t *testing.T) {
+	ctx := context.Background()
+
+	publishAllocs := func(subscribers int) float64 {
+		p := mediator.New()
+		for range subscribers {
+			_, err := mediator.Subscribe(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+				return nil
+			}))
+			require.NoError(t, err)
+		}
+		return testing.AllocsPerRun(100, func() {
+			_ = mediator.Publish(ctx, p, "some-event")
+		})
+	}
+
+	assert.Equal(t, publishAllocs(1), publishAllocs(21), "calling a handler shouldn't allocate")
+}
+
+func BenchmarkPublish(b *testing.B) {
+	ctx := context.Background()
+
+	for _, subscribers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("subscribers=%d", subscribers), func(b *testing.B) {
+			p := mediator.New()
+			handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+				return nil
+			})
+			for range subscribers {
+				_, err := mediator.Subscribe(p, handler)
+				require.NoError(b, err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				_ = mediator.Publish(ctx, p, "some-event")
+			}
+		})
+	}
+}
+
+func BenchmarkPublish_ConcurrentSubscribe(b *testing.B) {
+	ctx := context.Background()
+	p := mediator.New()
+	handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	})
+	for range 10 {
+		_, err := mediator.Subscribe(p, handler)
+		require.NoError(b, err)
+	}
+
+	// keep changing the subscribers while publishing
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				sub, _ := mediator.Subscribe(p, handler)
+				sub.Unsubscribe()
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = mediator.Publish(ctx, p, "some-event")
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+}
+
+type (
+	domainEvent interface {
+		AggregateID() string
+	}
+	userCreated struct {
+		id string
+	}
+	userDeleted struct {
+		id string
+	}
+)
+
+func (e userCreated) AggregateID() string { return e.id }
+
+func (e *userDeleted) AggregateID() string { return e.id }
+
+func TestSubscribeInterface(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New()
+
+	var order []string
+	_, err := mediator.SubscribeInterface(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, event domainEvent) error {
+		order = append(order, "interface:"+event.AggregateID())
+		return nil
+	}))
+	require.NoError(t, err)
+	_, err = mediator.Subscribe(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, event userCreated) error {
+		order = append(order, "exact:"+event.id)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, p, userCreated{id: "1"}))
+	// a pointer receiver means only the pointer implements the interface
+	require.NoError(t, mediator.Publish(ctx, p, userDeleted{id: "2"}))
+	require.NoError(t, mediator.Publish(ctx, p, &userDeleted{id: "3"}))
+	// the dynamic type decides when the notification is published as the interface
+	require.NoError(t, mediator.Publish[domainEvent](ctx, p, userCreated{id: "4"}))
+	require.NoError(t, mediator.Publish(ctx, p, "not a domain event"))
+
+	assert.Equal(t, []string{"exact:1", "interface:1", "interface:3", "interface:4"}, order)
+}
+
+func TestSubscribeInterface_Unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New()
+
+	var calls int
+	sub, err := mediator.SubscribeInterface(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ domainEvent) error {
+		calls++
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, p, userCreated{id: "1"}))
+	sub.Unsubscribe()
+	// the cached handlers for userCreated shouldn't be used anymore
+	require.NoError(t, mediator.Publish(ctx, p, userCreated{id: "2"}))
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestSubscribeInterface_NotAnInterface(t *testing.T) {
+	t.Parallel()
+
+	p := mediator.New()
+	_, err := mediator.SubscribeInterface(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ userCreated) error {
+		return nil
+	}))
+	require.Error(t, err)
+}
+
+func TestSubscribeAll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	behav := &testBehavior{}
+	p := mediator.New(mediator.WithNotificationBehaviors(behav))
+
+	var audit []string
+	sub, err := mediator.SubscribeAll(p, func(_ context.Context, _ *slog.Logger, msg mediator.NotificationMessage[any]) error {
+		assert.Equal(t, mediator.TypeNotification, msg.Type())
+		audit = append(audit, fmt.Sprintf("%s=%v", msg.String(), msg.GetInner()))
+		return nil
+	})
+	require.NoError(t, err)
+
+	var exactCalls int
+	_, err = mediator.Subscribe(p, mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ userCreated) error {
+		exactCalls++
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, p, userCreated{id: "1"}))
+	require.NoError(t, mediator.Publish(ctx, p, GopherCreatedEvent{gopher: Gopher{Name: "Gus"}}))
+
+	assert.Equal(t, []string{"userCreated={1}", "GopherCreatedEvent={{Gus  0}}"}, audit)
+	assert.Equal(t, 1, exactCalls)
+	assert.Equal(t, 3, behav.counter, "catch-all handlers should pass through the pipeline")
+
+	sub.Unsubscribe()
+	require.NoError(t, mediator.Publish(ctx, p, userCreated{id: "2"}))
+	assert.Len(t, audit, 2)
+}
+
+func TestSubscribeAll_Filter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New()
+
+	myErr := errors.New("bridge failed")
+	var forwarded []any
+	_, err := mediator.SubscribeAll(p, func(_ context.Context, _ *slog.Logger, msg mediator.NotificationMessage[any]) error {
+		if msg.String() != "userCreated" {
+			return nil
+		}
+		forwarded = append(forwarded, msg.GetNotification())
+		return myErr
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, p, "some-event"))
+	require.ErrorIs(t, mediator.Publish(ctx, p, userCreated{id: "1"}), myErr)
+	assert.Equal(t, []any{userCreated{id: "1"}}, forwarded)
+}
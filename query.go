@@ -0,0 +1,45 @@
+package mediator
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Query is a request that reads state and responds with T.
+// It can be sent through the [Mediator] using [SendQuery].
+//
+// A Query has the same shape as a [Request], the difference is the [Pipeline] it passes through.
+// Behaviors that are added with [WithQueryBehaviors] only run for queries.
+type Query[T any] interface {
+	// Handle executes the [Query].
+	// The [context.Context] parameter and the return values can be accessed and altered in the [Pipeline].
+	Handle(ctx context.Context, l *slog.Logger) (T, error)
+}
+
+// SendQuery sends a [Query] using a [Sender].
+//
+// The query passes through the query [Pipeline] as a [QueryMessage] with the [TypeQuery] type.
+// The query [Pipeline] consists of the request behaviors followed by the behaviors set with [WithQueryBehaviors].
+//
+// If a [Behavior] returns a response that isn't of type T, a [ResponseTypeError] is returned.
+// A nil response with an error results in the zero value of T.
+//
+// The [Sender] interface is implemented by [Mediator].
+func SendQuery[T any](ctx context.Context, m Sender, query Query[T], options ...SendOption) (T, error) {
+	opts := newSendOptions(options)
+	msg := queryMessage[T]{requestMessage: requestMessage[T]{req: query, metadata: newMetadata(ctx, opts.metadata), typed: requestChainFor[T](m, query)}}
+	resp, err := opts.handle(ctx, m, m.getQueryPipeline(), msg)
+	return responseAs[T](msg, resp, err)
+}
+
+// readOnlyGuard marks the context of a query as read-only, so [Dispatch] rejects commands.
+// It is the first behavior of the query [Pipeline] when [WithReadOnlyQueries] is used.
+type readOnlyGuard struct{}
+
+func (readOnlyGuard) Handler(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, l *slog.Logger, msg Message) (any, error) {
+		scope := scopeFromContext(ctx)
+		scope.readOnly = msg.String()
+		return next.Handle(contextWithScope(ctx, scope), l, msg)
+	})
+}
@@ -0,0 +1,160 @@
+package mediator_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+// recordBehavior records the name and message type of every message that passes through it.
+func recordBehavior(name string, calls *[]string) *testBehavior {
+	return &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+		*calls = append(*calls, fmt.Sprintf("%s:%s", name, msg.Type()))
+		return next.Handle(ctx, l, msg)
+	}}
+}
+
+func TestSendQuery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	gopher, err := mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name)
+}
+
+func TestSendQuery_ResponseTypeError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithQueryBehaviors(shortCircuit("not a gopher", nil)))
+
+	_, err := mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gus"))
+	var rtErr mediator.ResponseTypeError
+	require.ErrorAs(t, err, &rtErr)
+	assert.Equal(t, "searchGopher", rtErr.Request)
+}
+
+func TestKindBehaviors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls []string
+	m := mediator.New(
+		mediator.WithRequestBehaviors(recordBehavior("request", &calls)),
+		mediator.WithQueryBehaviors(recordBehavior("query", &calls)),
+		mediator.WithCommandBehaviors(recordBehavior("command", &calls)),
+	)
+
+	_, err := mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request:query", "query:query"}, calls)
+
+	calls = nil
+	require.NoError(t, mediator.Dispatch(ctx, m, &renameGopher{name: "Gus"}))
+	assert.Equal(t, []string{"request:command", "command:command"}, calls)
+
+	calls = nil
+	_, err = mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request:request"}, calls)
+}
+
+func TestKindBehaviors_CustomRequestPipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls []string
+	pipeline := &customPipeline{}
+	m := mediator.New(
+		mediator.WithRequestPipeline(pipeline),
+		mediator.WithQueryBehaviors(recordBehavior("query", &calls)),
+	)
+
+	_, err := mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.True(t, pipeline.isCalled, "custom pipeline is not used")
+	assert.Equal(t, []string{"query:query"}, calls)
+}
+
+func TestSenderFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+	assert.Nil(t, mediator.SenderFromContext(ctx))
+
+	var sender mediator.Sender
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, _ findGopher) (Gopher, error) {
+		sender = mediator.SenderFromContext(ctx)
+		return Gopher{}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{})
+	require.NoError(t, err)
+	assert.Same(t, m, sender)
+}
+
+// renamingQuery is a query that (wrongly) dispatches a command.
+type renamingQuery struct {
+	cmd *renameGopher
+}
+
+func (q renamingQuery) Handle(ctx context.Context, _ *slog.Logger) (Gopher, error) {
+	err := mediator.Dispatch(ctx, mediator.SenderFromContext(ctx), q.cmd)
+	return q.cmd.gopher, err
+}
+
+func TestReadOnlyQueries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithReadOnlyQueries())
+
+	cmd := &renameGopher{name: "Gus"}
+	_, err := mediator.SendQuery(ctx, m, renamingQuery{cmd: cmd})
+	var roErr mediator.ErrReadOnly
+	require.ErrorAs(t, err, &roErr)
+	assert.Equal(t, "renamingQuery", roErr.Query)
+	assert.Empty(t, cmd.gopher.Name, "the command shouldn't be handled")
+
+	// commands can still be dispatched outside of queries
+	require.NoError(t, mediator.Dispatch(ctx, m, cmd))
+	assert.Equal(t, "Gus", cmd.gopher.Name)
+}
+
+func TestReadOnlyQueries_Nested(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithReadOnlyQueries())
+
+	// the request sends a query that dispatches a command
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, _ findGopher) (Gopher, error) {
+		return mediator.SendQuery(ctx, m, renamingQuery{cmd: &renameGopher{name: "Gus"}})
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{})
+	require.ErrorAs(t, err, &mediator.ErrReadOnly{})
+}
+
+func TestReadOnlyQueries_Disabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	gopher, err := mediator.SendQuery(ctx, m, renamingQuery{cmd: &renameGopher{name: "Gus"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name)
+}
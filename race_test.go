@@ -0,0 +1,6 @@
+//go:build race
+
+package mediator_test
+
+// raceEnabled reports if the race detector is enabled, it adds allocations to the code it instruments.
+const raceEnabled = true
@@ -0,0 +1,224 @@
+package mediator
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// notifierRegistry holds the notification handlers per notification type.
+	// It is shared by [Mediator] and the fake mediator.
+	//
+	// The handlers are stored in an immutable snapshot that is replaced on every change (copy-on-write).
+	// Reading the handlers is lock-free and doesn't allocate,
+	// changes are serialized by a mutex.
+	notifierRegistry struct {
+		mu       sync.Mutex
+		snapshot atomic.Pointer[notifierSnapshot]
+	}
+	// notifierSnapshot maps a key to the typed handlers, for example key[T] to *notifiers[T].
+	// The notifiers of a snapshot are never changed after it is stored.
+	notifierSnapshot struct {
+		notifiers map[any]any
+		// dispatch caches the handlers that are resolved for a published type, see resolveHandlers.
+		// Every snapshot starts with an empty cache, so the cache is never outdated.
+		dispatch sync.Map
+	}
+
+	// notifiers holds the handlers for one notification type.
+	// The typed slice is cached, so publishing doesn't have to convert the handlers.
+	// Like the snapshot, it is never changed after it is stored.
+	notifiers[T any] struct {
+		ids      []uint64
+		handlers []NotificationHandler[T]
+	}
+
+	// interfaceKey is the key of the []interfaceNotifier slice in the snapshot.
+	interfaceKey struct{}
+	// interfaceNotifier is a handler that is subscribed to an interface type with [SubscribeInterface].
+	interfaceNotifier struct {
+		id uint64
+		// matches reports if the notification implements the interface.
+		matches func(notification any) bool
+		handle  func(ctx context.Context, l *slog.Logger, notification any) error
+	}
+	// interfaceHandler calls an interfaceNotifier for notifications of type T.
+	interfaceHandler[T any] struct {
+		handle func(ctx context.Context, l *slog.Logger, notification any) error
+	}
+)
+
+// notifierIDs generates the ids that are used to unsubscribe handlers.
+var notifierIDs atomic.Uint64
+
+func newNotifierRegistry() *notifierRegistry {
+	r := &notifierRegistry{}
+	r.snapshot.Store(&notifierSnapshot{})
+	return r
+}
+
+// loadNotifiers returns the current snapshot.
+func (r *notifierRegistry) loadNotifiers() *notifierSnapshot {
+	return r.snapshot.Load()
+}
+
+// updateNotifiers replaces the handlers for the given key with the result of the update function.
+// The update function gets the current handlers and must not change them, it should return a copy instead.
+// Returning nil removes the key.
+func (r *notifierRegistry) updateNotifiers(key any, update func(current any) any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := &notifierSnapshot{notifiers: maps.Clone(r.snapshot.Load().notifiers)}
+	if snapshot.notifiers == nil {
+		snapshot.notifiers = make(map[any]any)
+	}
+	if v := update(snapshot.notifiers[key]); v != nil {
+		snapshot.notifiers[key] = v
+	} else {
+		delete(snapshot.notifiers, key)
+	}
+	r.snapshot.Store(snapshot)
+}
+
+// with returns a copy of n with the handler added.
+func (n *notifiers[T]) with(id uint64, handler NotificationHandler[T]) *notifiers[T] {
+	if n == nil {
+		n = &notifiers[T]{}
+	}
+	return &notifiers[T]{
+		ids:      append(n.ids[:len(n.ids):len(n.ids)], id),
+		handlers: append(n.handlers[:len(n.handlers):len(n.handlers)], handler),
+	}
+}
+
+// without returns a copy of n without the handler, or nil if there are no handlers left.
+func (n *notifiers[T]) without(id uint64) *notifiers[T] {
+	if n == nil {
+		return nil
+	}
+	res := &notifiers[T]{
+		ids:      make([]uint64, 0, len(n.ids)),
+		handlers: make([]NotificationHandler[T], 0, len(n.handlers)),
+	}
+	for i, nid := range n.ids {
+		if nid != id {
+			res.ids = append(res.ids, nid)
+			res.handlers = append(res.handlers, n.handlers[i])
+		}
+	}
+	if len(res.ids) == 0 {
+		return nil
+	}
+	return res
+}
+
+// addNotifier subscribes the handler under the given key and returns the id that removes it again.
+func addNotifier[T any](p Publisher, key any, handler NotificationHandler[T]) uint64 {
+	id := notifierIDs.Add(1)
+	p.updateNotifiers(key, func(current any) any {
+		n, _ := current.(*notifiers[T])
+		return n.with(id, handler)
+	})
+	return id
+}
+
+// removeNotifier removes the handler with the given id from the key.
+func removeNotifier[T any](p Publisher, key any, id uint64) {
+	p.updateNotifiers(key, func(current any) any {
+		n, _ := current.(*notifiers[T])
+		if n = n.without(id); n == nil {
+			// don't return a typed nil pointer, the key would never be removed
+			return nil
+		}
+		return n
+	})
+}
+
+// getHandlers returns the handlers that are subscribed under the given key.
+func getHandlers[T any](snapshot *notifierSnapshot, key any) []NotificationHandler[T] {
+	n, _ := snapshot.notifiers[key].(*notifiers[T])
+	if n == nil {
+		return nil
+	}
+	return n.handlers
+}
+
+// resolveHandlers returns the handlers for a published notification of type T.
+// These are the handlers that are subscribed to T,
+// followed by the handlers of interfaces that T implements.
+//
+// The interface handlers are resolved once per type and cached in the snapshot.
+// Only when T is an interface itself, the handlers depend on the dynamic type and are resolved for every call.
+func resolveHandlers[T any](p Publisher, notification T) []NotificationHandler[T] {
+	snapshot := p.loadNotifiers()
+	handlers := getHandlers[T](snapshot, key[T]{})
+	ifaces, _ := snapshot.notifiers[interfaceKey{}].([]interfaceNotifier)
+	if len(ifaces) == 0 {
+		return handlers
+	}
+
+	// the zero value of T is only nil if T is an interface
+	var zero T
+	cacheable := any(zero) != nil
+	if cacheable {
+		if cached, ok := snapshot.dispatch.Load(key[T]{}); ok {
+			return cached.([]NotificationHandler[T])
+		}
+	}
+
+	n := any(notification)
+	handlers = slices.Clip(handlers)
+	for _, in := range ifaces {
+		if in.matches(n) {
+			handlers = append(handlers, interfaceHandler[T]{handle: in.handle})
+		}
+	}
+
+	if cacheable {
+		snapshot.dispatch.Store(key[T]{}, handlers)
+	}
+	return handlers
+}
+
+func (h interfaceHandler[T]) Handle(ctx context.Context, l *slog.Logger, event T) error {
+	return h.handle(ctx, l, event)
+}
+
+// addInterfaceNotifier subscribes the handler to every notification that implements I.
+func addInterfaceNotifier[I any](p Publisher, handler NotificationHandler[I]) uint64 {
+	id := notifierIDs.Add(1)
+	in := interfaceNotifier{
+		id: id,
+		matches: func(notification any) bool {
+			_, ok := notification.(I)
+			return ok
+		},
+		handle: func(ctx context.Context, l *slog.Logger, notification any) error {
+			return handler.Handle(ctx, l, notification.(I))
+		},
+	}
+	p.updateNotifiers(interfaceKey{}, func(current any) any {
+		ifaces, _ := current.([]interfaceNotifier)
+		return append(slices.Clip(ifaces), in)
+	})
+	return id
+}
+
+// removeInterfaceNotifier removes the interface handler with the given id.
+func removeInterfaceNotifier(p Publisher, id uint64) {
+	p.updateNotifiers(interfaceKey{}, func(current any) any {
+		ifaces, _ := current.([]interfaceNotifier)
+		ifaces = slices.DeleteFunc(slices.Clone(ifaces), func(in interfaceNotifier) bool {
+			return in.id == id
+		})
+		if len(ifaces) == 0 {
+			return nil
+		}
+		return ifaces
+	})
+}
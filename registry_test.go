@@ -0,0 +1,129 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // allocations are measured globally, so other tests can't run at the same time
+func TestNotifierRegistry_GetHandlersDoesNotAllocate(t *testing.T) {
+	m := New()
+	handler := NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	})
+	for range 10 {
+		_, err := Subscribe(m, handler)
+		require.NoError(t, err)
+	}
+
+	var handlers []NotificationHandler[string]
+	allocs := testing.AllocsPerRun(100, func() {
+		handlers = getHandlers[string](m.(*mediator).loadNotifiers(), key[string]{})
+	})
+	assert.Len(t, handlers, 10)
+	assert.Zero(t, allocs, "looking up the handlers of a notification type shouldn't allocate")
+}
+
+//nolint:paralleltest // allocations are measured globally, so other tests can't run at the same time
+func TestNotifierRegistry_ResolveInterfaceHandlersIsCached(t *testing.T) {
+	m := New()
+	_, err := Subscribe(m, NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	}))
+	require.NoError(t, err)
+	_, err = SubscribeInterface(m, NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ fmt.Stringer) error {
+		return nil
+	}))
+	require.NoError(t, err)
+	_, err = SubscribeInterface(m, NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ any) error {
+		return nil
+	}))
+	require.NoError(t, err)
+
+	// the first call resolves and caches the handlers
+	assert.Len(t, resolveHandlers(m, "some-event"), 2)
+
+	var handlers []NotificationHandler[string]
+	allocs := testing.AllocsPerRun(100, func() {
+		handlers = resolveHandlers(m, "some-event")
+	})
+	assert.Len(t, handlers, 2, "string only implements the any interface")
+	assert.Zero(t, allocs, "resolved handlers should be cached")
+}
+
+func TestNotifierRegistry_SnapshotIsImmutable(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	handler := NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	})
+	sub, err := Subscribe(m, handler)
+	require.NoError(t, err)
+	_, err = Subscribe(m, handler)
+	require.NoError(t, err)
+
+	// a publish that is in flight holds on to the handlers it looked up
+	inFlight := getHandlers[string](m.(*mediator).loadNotifiers(), key[string]{})
+	sub.Unsubscribe()
+	_, err = Subscribe(m, handler)
+	require.NoError(t, err)
+	_, err = Subscribe(m, handler)
+	require.NoError(t, err)
+
+	assert.Len(t, inFlight, 2, "changes to the registry shouldn't affect handlers that were already looked up")
+	assert.Len(t, getHandlers[string](m.(*mediator).loadNotifiers(), key[string]{}), 3)
+}
+
+func TestNotifierRegistry_RemoveLastHandler(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	handler := NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	})
+	sub, err := Subscribe(m, handler)
+	require.NoError(t, err)
+	sub.Unsubscribe()
+
+	assert.Nil(t, m.(*mediator).loadNotifiers().notifiers[key[string]{}], "the key should be removed when it has no handlers left")
+}
+
+func TestNotifierRegistry_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	handler := NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ int) error {
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				for _, h := range getHandlers[int](m.(*mediator).loadNotifiers(), key[int]{}) {
+					assert.NotNil(t, h)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				sub, err := Subscribe(m, handler)
+				assert.NoError(t, err)
+				sub.Unsubscribe()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, getHandlers[int](m.(*mediator).loadNotifiers(), key[int]{}))
+}
@@ -0,0 +1,149 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+type (
+	// ResponseTypeError is returned when the response that comes out of the [Pipeline]
+	// can't be used as the response of the [Request].
+	// This happens when a [Behavior] replaces the response with a value of the wrong type,
+	// or short-circuits with a nil response without returning an error.
+	ResponseTypeError struct {
+		// Request is the name of the request.
+		Request string
+		// Behavior is the type of the [Behavior] that returned the response.
+		// It is empty when the behavior is unknown, for example when a custom [Pipeline] is used.
+		Behavior string
+		// Expected is the response type of the request.
+		Expected string
+		// Actual is the type of the response that was returned.
+		Actual string
+	}
+
+	// responseMessage is implemented by messages that expect a response of a specific type.
+	responseMessage interface {
+		// checkResponse returns a [ResponseTypeError] if resp can't be used as the response of the message.
+		checkResponse(resp any) *ResponseTypeError
+		zeroResponse() any
+	}
+
+	// streamMessage is implemented by the messages of a [StreamRequest].
+	streamMessage interface {
+		isStream()
+	}
+)
+
+func (e ResponseTypeError) Error() string {
+	source := "the pipeline"
+	if e.Behavior != "" {
+		source = "behavior " + e.Behavior
+	}
+	return fmt.Sprintf("%s returned a response of type %s for request %s, expected %s", source, e.Actual, e.Request, e.Expected)
+}
+
+// ZeroResponse returns the zero value of the response type of the message, boxed in an any.
+// A [Behavior] can use it to short-circuit a [Request] with a correctly typed response.
+// It returns nil for messages that don't have a response, like notifications.
+func ZeroResponse(msg Message) any {
+	if rm, ok := msg.(responseMessage); ok {
+		return rm.zeroResponse()
+	}
+	return nil
+}
+
+// IsStream reports if the message is a [StreamRequest] that is sent with [SendStream].
+// The results of a stream are yielded to the caller while it passes through the [Pipeline],
+// so the response that a [Behavior] receives from the next handler is always nil.
+// Behaviors that store or share responses should pass stream messages on to the next handler.
+func IsStream(msg Message) bool {
+	_, ok := msg.(streamMessage)
+	return ok
+}
+
+// Respond checks if resp can be used as the response of the message and returns it.
+// A [Behavior] that replaces a response should use it, so a wrong type results in a [ResponseTypeError]
+// that names the request instead of an error in the caller.
+//
+//	return mediator.Respond(msg, cachedGopher)
+func Respond[T any](msg Message, resp T) (any, error) {
+	if rm, ok := msg.(responseMessage); ok {
+		if rtErr := rm.checkResponse(resp); rtErr != nil {
+			return nil, *rtErr
+		}
+	}
+	return resp, nil
+}
+
+// responseAs converts the response of the pipeline to T.
+// A nil response with an error results in the zero value of T.
+//
+// The message is a type parameter instead of an interface, so it doesn't have to be boxed again.
+func responseAs[T any, M responseMessage](msg M, resp any, err error) (T, error) {
+	if respT, ok := resp.(T); ok {
+		return respT, err
+	}
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+	if rtErr := msg.checkResponse(resp); rtErr != nil {
+		return zero, *rtErr
+	}
+	return zero, nil
+}
+
+// checkResponse checks if resp can be used as a response of type T.
+// A nil response is allowed when the zero value of T is nil, like a pointer or a slice.
+// The caller sets the name of the request when the check fails.
+func checkResponse[T any](resp any) *ResponseTypeError {
+	if _, ok := resp.(T); ok {
+		return nil
+	}
+	expected := reflect.TypeFor[T]()
+	if resp == nil && isNillable(expected) {
+		return nil
+	}
+
+	actual := "nil"
+	if resp != nil {
+		actual = reflect.TypeOf(resp).String()
+	}
+	return &ResponseTypeError{
+		Expected: expected.String(),
+		Actual:   actual,
+	}
+}
+
+func isNillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkedHandler checks the response of a [Behavior] in the compiled chain,
+// so a [ResponseTypeError] can name the behavior that returned it.
+type checkedHandler struct {
+	behavior string
+	next     Handler
+}
+
+func (h *checkedHandler) Handle(ctx context.Context, l *slog.Logger, msg Message) (any, error) {
+	resp, err := h.next.Handle(ctx, l, msg)
+	if err != nil {
+		return resp, err
+	}
+	if rm, ok := msg.(responseMessage); ok {
+		if rtErr := rm.checkResponse(resp); rtErr != nil {
+			rtErr.Behavior = h.behavior
+			return nil, *rtErr
+		}
+	}
+	return resp, nil
+}
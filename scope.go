@@ -0,0 +1,88 @@
+package mediator
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+type (
+	// messageScope is stored in the context of a message that is being handled.
+	// The values are stored together, so handling a message only adds one value to the context.
+	messageScope struct {
+		metadata Metadata
+		// handler holds the metadata of a notification handler, it is used instead of metadata when it is set
+		handler *handlerMetadata
+		sender  Sender
+		// readOnly is the name of the query that is being handled, if the query pipeline is read-only
+		readOnly string
+		// buffer queues the notifications that are published, if a unit of work is in progress
+		buffer *PublishBuffer
+		// message is the message that is being handled,
+		// it is handled at the end of the chain when a behavior replaced it with an unknown message type
+		message Message
+	}
+	scopeKey struct{}
+
+	// handlerMetadata is the metadata of one handler of a published notification.
+	// The handlers share the metadata of the notification, so publishing doesn't copy it for every handler.
+	// A handler gets its own copy when it accesses the metadata, so it can't change the metadata of the other handlers.
+	handlerMetadata struct {
+		once   sync.Once
+		shared Metadata
+		own    Metadata
+	}
+
+	// scopeContext holds the scope, it replaces [context.WithValue] so adding a scope only allocates once.
+	scopeContext struct {
+		context.Context
+		scope messageScope
+	}
+)
+
+// Value returns the scope for the scopeKey, other keys are looked up in the parent context.
+func (c *scopeContext) Value(key any) any {
+	if key == (scopeKey{}) {
+		return &c.scope
+	}
+	return c.Context.Value(key)
+}
+
+// contextWithScope returns a copy of the context that holds the scope.
+func contextWithScope(ctx context.Context, scope messageScope) context.Context {
+	return &scopeContext{Context: ctx, scope: scope}
+}
+
+// scopeFromContext returns a copy of the scope in the context, it is empty if the context has no scope.
+func scopeFromContext(ctx context.Context) messageScope {
+	if scope, ok := ctx.Value(scopeKey{}).(*messageScope); ok {
+		return *scope
+	}
+	return messageScope{}
+}
+
+// getMetadata returns the metadata of the message that is being handled.
+func (s messageScope) getMetadata() Metadata {
+	if s.handler != nil {
+		return s.handler.get()
+	}
+	return s.metadata
+}
+
+// get returns the copy of the metadata of the handler, it is copied on the first call.
+func (h *handlerMetadata) get() Metadata {
+	h.once.Do(func() {
+		h.own = maps.Clone(h.shared)
+	})
+	return h.own
+}
+
+// SenderFromContext returns the [Sender] that is handling the current request, command or query,
+// or nil if there is none.
+//
+// Handlers can use it to send other messages, without holding a reference to the [Mediator].
+// Commands that are dispatched through it are rejected with an [ErrReadOnly] error
+// while a query is handled by a [Mediator] that is created with [WithReadOnlyQueries].
+func SenderFromContext(ctx context.Context) Sender {
+	return scopeFromContext(ctx).sender
+}
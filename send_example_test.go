@@ -81,6 +81,30 @@ func ExampleSend() {
 	// found a gopher: {Gus blue 100}
 }
 
+func ExampleSendRequest() {
+	ctx := context.Background()
+
+	m := mediator.New(mediator.WithRequestBehaviors(NewExampleLogger()))
+
+	// register a handler for a plain request struct
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		return Gopher{Name: req.name, Color: "green", CutenessLevel: 90}, nil
+	})
+	if err := mediator.RegisterHandler(m, handler); err != nil {
+		return
+	}
+
+	gopher, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	if err != nil {
+		return
+	}
+	fmt.Printf("found a gopher: %v\n", gopher)
+
+	// Output:
+	// Request: findGopher request={Gus}
+	// found a gopher: {Gus green 90}
+}
+
 func ExamplePublish() {
 	ctx := context.Background()
 
@@ -89,7 +113,7 @@ func ExamplePublish() {
 
 	// create a new notification handler and subscribe it
 	myHandler := NewCreatedGophersCounter()
-	err := mediator.Subscribe(m, myHandler)
+	_, err := mediator.Subscribe(m, myHandler)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,114 @@
+package mediator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type (
+	// SendOption defines the method to customize sending a [Request] with [Send], [SendRequest] or [SendStream].
+	SendOption  func(*sendOptions)
+	sendOptions struct {
+		l         *slog.Logger
+		timeout   time.Duration
+		metadata  Metadata
+		behaviors []Behavior
+		pipeline  Pipeline
+	}
+)
+
+// WithSendLogger is used to add a custom logger instance to the request.
+// Passing a logger can be useful if you want to add attributes to the logger in the caller.
+func WithSendLogger(l *slog.Logger) SendOption {
+	return func(o *sendOptions) {
+		o.l = l
+	}
+}
+
+// WithTimeout cancels the context of the request after the given duration.
+// The timeout includes the time spent in the behaviors of the [Pipeline].
+// For [SendStream], the timeout is for consuming the whole stream.
+func WithTimeout(timeout time.Duration) SendOption {
+	return func(o *sendOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithSendMetadata adds [Metadata] to the message that is sent.
+// The given values take precedence over the values that are inherited from the context.
+func WithSendMetadata(md Metadata) SendOption {
+	return func(o *sendOptions) {
+		o.metadata = md
+	}
+}
+
+// WithSendBehaviors adds behaviors for this request only.
+// They are wrapped around the request [Pipeline], so they run before the behaviors of the [Mediator].
+//
+// Unlike the behaviors of the [Mediator], these behaviors are chained for every call.
+func WithSendBehaviors(behaviors ...Behavior) SendOption {
+	return func(o *sendOptions) {
+		o.behaviors = append(o.behaviors, behaviors...)
+	}
+}
+
+// WithSendPipeline replaces the request [Pipeline] of the [Mediator] for this request.
+// Behaviors added with [WithSendBehaviors] are still wrapped around it.
+func WithSendPipeline(pl Pipeline) SendOption {
+	return func(o *sendOptions) {
+		o.pipeline = pl
+	}
+}
+
+// newSendOptions applies the options.
+func newSendOptions(options []SendOption) sendOptions {
+	var opts sendOptions
+	for _, o := range options {
+		o(&opts)
+	}
+	return opts
+}
+
+// logger returns the logger of the options, or the logger of the [Sender] if none is set.
+func (o sendOptions) logger(m Sender) *slog.Logger {
+	if o.l != nil {
+		return o.l
+	}
+	return m.getLogger()
+}
+
+// handler returns the handler chain for the request.
+// The [Pipeline] is used if the options don't override it.
+func (o sendOptions) handler(pl Pipeline) Handler {
+	if o.pipeline != nil {
+		pl = o.pipeline
+	}
+	h := pipelineHandler(pl)
+	if len(o.behaviors) == 0 {
+		return h
+	}
+	return pipeline{behaviors: o.behaviors}.Then(h.Handle)
+}
+
+// handle passes the message through the handler chain of the [Pipeline], with the context of [sendOptions.context].
+func (o sendOptions) handle(ctx context.Context, m Sender, pl Pipeline, msg Message) (any, error) {
+	ctx, cancel := o.context(ctx, m, msg)
+	defer cancel()
+	return o.handler(pl).Handle(ctx, o.logger(m), msg)
+}
+
+// context returns the context the message is handled with, it holds the metadata, the [Sender] and the timeout.
+// It holds the message as well, so the message is still handled when a behavior replaces it with an unknown message type.
+// The returned cancel function should always be called.
+func (o sendOptions) context(ctx context.Context, m Sender, msg Message) (context.Context, context.CancelFunc) {
+	scope := scopeFromContext(ctx)
+	scope.metadata, scope.handler = msg.Metadata(), nil
+	scope.sender = m
+	scope.message = msg
+	ctx = contextWithScope(ctx, scope)
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
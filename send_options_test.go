@@ -0,0 +1,124 @@
+package mediator_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+func TestSendOption_Logger(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := mediator.NewRequestHandler(func(_ context.Context, l *slog.Logger, _ findGopher) (Gopher, error) {
+		l.Info("handling request")
+		return Gopher{}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{}, mediator.WithSendLogger(l))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "handling request")
+}
+
+func TestSendOption_Timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	handler := mediator.NewRequestHandler(func(ctx context.Context, _ *slog.Logger, _ findGopher) (Gopher, error) {
+		<-ctx.Done()
+		return Gopher{}, ctx.Err()
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{}, mediator.WithTimeout(10*time.Millisecond))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSendOption_TimeoutStream(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	names := make([]string, 100)
+	var results int
+	var streamErr error
+	for _, err := range mediator.SendStream(ctx, m, listGophers{names: names}, mediator.WithTimeout(20*time.Millisecond)) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+		results++
+		// a slow consumer
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.ErrorIs(t, streamErr, context.DeadlineExceeded)
+	assert.Less(t, results, len(names))
+}
+
+func TestSendOption_Behaviors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var order []string
+	record := func(name string) *testBehavior {
+		return &testBehavior{handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+			order = append(order, name)
+			return next.Handle(ctx, l, msg)
+		}}
+	}
+	m := mediator.New(mediator.WithRequestBehaviors(record("mediator")))
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"), mediator.WithSendBehaviors(record("call-1"), record("call-2")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"call-1", "call-2", "mediator"}, order)
+
+	// the behaviors are only used for the call they are passed to
+	order = nil
+	_, err = mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mediator"}, order)
+}
+
+func TestSendOption_BehaviorResponseIsChecked(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"), mediator.WithSendBehaviors(shortCircuit("not a gopher", nil)))
+	var rtErr mediator.ResponseTypeError
+	require.ErrorAs(t, err, &rtErr)
+	assert.Equal(t, "*mediator_test.testBehavior", rtErr.Behavior)
+}
+
+func TestSendOption_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	mediatorBehav := &testBehavior{}
+	m := mediator.New(mediator.WithRequestBehaviors(mediatorBehav))
+
+	pipeline := &customPipeline{}
+	gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"), mediator.WithSendPipeline(pipeline))
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name)
+	assert.True(t, pipeline.isCalled, "custom pipeline is not used")
+	assert.Zero(t, mediatorBehav.counter, "the pipeline of the mediator should be replaced")
+}
@@ -2,7 +2,10 @@ package mediator
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"reflect"
 )
 
 type (
@@ -12,7 +15,12 @@ type (
 	// The interface is implemented by [Mediator].
 	Sender interface {
 		getRequestPipeline() Pipeline
+		getQueryPipeline() Pipeline
+		getCommandPipeline() Pipeline
+		getTypedBehaviors(key typedKey) any
 		getLogger() *slog.Logger
+		getRequestHandler(key any) (any, bool)
+		setRequestHandler(key any, handler any)
 	}
 
 	// Request is an object that can be sent through the [Mediator].
@@ -26,14 +34,76 @@ type (
 		// The [context.Context] parameter and the return values can be accessed and altered in the [Pipeline].
 		Handle(ctx context.Context, l *slog.Logger) (T, error)
 	}
+
+	// RequestHandler handles requests of type Req and responds with Resp.
+	// RequestHandlers are registered on a [Sender] with [RegisterHandler] and used by [SendRequest].
+	//
+	// Unlike [Request], the request type doesn't need a Handle function.
+	// This allows plain data structs to be sent through the [Mediator].
+	RequestHandler[Req any, Resp any] interface {
+		Handle(ctx context.Context, l *slog.Logger, req Req) (Resp, error)
+	}
+
+	requestHandler[Req any, Resp any] struct {
+		handleFunc func(ctx context.Context, l *slog.Logger, req Req) (Resp, error)
+	}
+
+	// ErrNoHandler is returned by [SendRequest] when no [RequestHandler] is registered for the request type.
+	ErrNoHandler struct {
+		// Request is the name of the request type.
+		Request string
+	}
 )
 
+func (rh requestHandler[Req, Resp]) Handle(ctx context.Context, l *slog.Logger, req Req) (Resp, error) {
+	return rh.handleFunc(ctx, l, req)
+}
+
+func (e ErrNoHandler) Error() string {
+	return fmt.Sprintf("no handler registered for request %s", e.Request)
+}
+
+// NewRequestHandler is a utility function for creating a [RequestHandler] without having to define a type.
+// This is especially useful when writing tests.
+func NewRequestHandler[Req any, Resp any](handleFunc func(ctx context.Context, l *slog.Logger, req Req) (Resp, error)) RequestHandler[Req, Resp] {
+	return requestHandler[Req, Resp]{
+		handleFunc: handleFunc,
+	}
+}
+
+// RegisterHandler registers a [RequestHandler] for requests of type Req on the [Sender].
+// Only one handler can be registered per request and response type,
+// registering a new handler for the same types replaces the previous one.
+//
+// The handler is used by [SendRequest], and by [Send] and [SendQuery] when Req is the exact type of the request,
+// so it takes precedence over the Handle function of a [Request].
+//
+// The [Sender] interface is implemented by [Mediator].
+func RegisterHandler[Req any, Resp any](m Sender, h RequestHandler[Req, Resp]) error {
+	if h == nil {
+		return errors.New("request handler can't be nil")
+	}
+	m.setRequestHandler(handlerKey[Req, Resp]{}, h)
+	// Send only knows the dynamic type of the request, so the handler is registered by its type as well
+	m.setRequestHandler(
+		typedKey{req: reflect.TypeFor[Req](), resp: reflect.TypeFor[Resp]()},
+		handlerChain[Req, Resp]{handler: h, typed: typedBehaviorsFor[Req, Resp](m)},
+	)
+	return nil
+}
+
 // Send a [Request] using a [Sender].
 // This function uses reflect to decide the name of the request.
+// If a [RequestHandler] is registered for the request type with [RegisterHandler], it handles the request.
+// The [SendOption] parameters can be used to customize a single call,
+// like adding a timeout or behaviors.
+//
+// If a [Behavior] returns a response that isn't of type T, a [ResponseTypeError] is returned.
+// A nil response with an error results in the zero value of T.
 //
 // The [Sender] interface is implemented by [Mediator].
-func Send[T any](ctx context.Context, m Sender, req Request[T]) (T, error) {
-	return SendWithLogger(ctx, m.getLogger(), m, req)
+func Send[T any](ctx context.Context, m Sender, req Request[T], options ...SendOption) (T, error) {
+	return send(ctx, m, req, newSendOptions(options))
 }
 
 // SendWithLogger a [Request] with a logger instance using a [Sender].
@@ -41,11 +111,35 @@ func Send[T any](ctx context.Context, m Sender, req Request[T]) (T, error) {
 // Passing a logger can be useful if you want to add attributes to the logger in the caller.
 //
 // The [Sender] interface is implemented by [Mediator].
+//
+// Deprecated: Use [Send] with the [WithSendLogger] send option instead.
 func SendWithLogger[T any](ctx context.Context, l *slog.Logger, m Sender, req Request[T]) (T, error) {
-	handler := m.getRequestPipeline().Then(func(ctx context.Context, l *slog.Logger, _ Message) (any, error) {
-		return req.Handle(ctx, l)
-	})
-	resp, err := handler.Handle(ctx, l, NewRequestMessage(req))
-	respT := resp.(T)
-	return respT, err
+	return send(ctx, m, req, sendOptions{l: l})
+}
+
+func send[T any](ctx context.Context, m Sender, req Request[T], opts sendOptions) (T, error) {
+	msg := requestMessage[T]{req: req, metadata: newMetadata(ctx, opts.metadata), typed: requestChainFor(m, req)}
+	resp, err := opts.handle(ctx, m, m.getRequestPipeline(), msg)
+	return responseAs[T](msg, resp, err)
+}
+
+// SendRequest sends a request of type Req to the [RequestHandler] registered for that type and Resp with [RegisterHandler].
+// If no handler is registered, but the request implements [Request], the request handles itself like it would with [Send].
+// Otherwise, an [ErrNoHandler] error is returned.
+//
+// The response type can't be inferred, so it has to be passed explicitly:
+//
+//	gopher, err := mediator.SendRequest[Gopher](ctx, m, searchGopher{name: "Gus"})
+//
+// The [Sender] interface is implemented by [Mediator].
+func SendRequest[Resp any, Req any](ctx context.Context, m Sender, req Req, options ...SendOption) (Resp, error) {
+	opts := newSendOptions(options)
+	var handler RequestHandler[Req, Resp]
+	if h, ok := m.getRequestHandler(handlerKey[Req, Resp]{}); ok {
+		handler = h.(RequestHandler[Req, Resp])
+	}
+	msg := newHandlerRequestMessage(req, handler, newMetadata(ctx, opts.metadata))
+	msg.typed = typedBehaviorsFor[Req, Resp](m)
+	resp, err := opts.handle(ctx, m, m.getRequestPipeline(), msg)
+	return responseAs[Resp](msg, resp, err)
 }
@@ -2,6 +2,8 @@ package mediator_test
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,17 +14,32 @@ import (
 	"github.com/luukvdm/mediator/mocks"
 )
 
+// callerKey is the key of a context value that the tests set, so mocks can check that they get the context of the caller.
+type callerKey struct{}
+
+// callerContext returns a context with a value under [callerKey].
+func callerContext() context.Context {
+	return context.WithValue(context.Background(), callerKey{}, "caller")
+}
+
+// fromCaller matches a context that is derived from a [callerContext].
+func fromCaller() any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(callerKey{}) == "caller"
+	})
+}
+
 func TestSend(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 
 	behav := &testBehavior{}
 	m := mediator.New(mediator.WithRequestBehaviors(behav))
 
 	msg := "test123"
 	req := mocks.NewMockRequest[string](t)
-	req.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger")).Return(msg, nil)
+	req.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger")).Return(msg, nil)
 
 	resp, err := mediator.Send[string](ctx, m, req)
 	require.NoError(t, err)
@@ -34,13 +51,13 @@ func TestSend(t *testing.T) {
 func TestSend_BehaviorPersistence(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := callerContext()
 
 	behav := &testBehavior{}
 	m := mediator.New(mediator.WithRequestBehaviors(behav))
 
 	req := mocks.NewMockRequest[string](t)
-	req.EXPECT().Handle(ctx, mock.AnythingOfType("*slog.Logger")).Return("test-123", nil)
+	req.EXPECT().Handle(fromCaller(), mock.AnythingOfType("*slog.Logger")).Return("test-123", nil)
 
 	rounds := 5
 	for i := 0; i < rounds; i++ {
@@ -49,3 +66,272 @@ func TestSend_BehaviorPersistence(t *testing.T) {
 	}
 	assert.Equal(t, rounds, behav.counter, "send seems to use copies of the behavior instead of reusing them (or not using them at all)")
 }
+
+type findGopher struct {
+	name string
+}
+
+func TestSendRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	behav := &testBehavior{}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		return Gopher{Name: req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	resp, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", resp.Name)
+	assert.Equal(t, 1, behav.counter, "middleware called unexpected amount of times")
+}
+
+func TestSendRequest_SwapHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	first := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, _ findGopher) (string, error) {
+		return "first", nil
+	})
+	second := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, _ findGopher) (string, error) {
+		return "second", nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, first))
+	require.NoError(t, mediator.RegisterHandler(m, second))
+
+	resp, err := mediator.SendRequest[string](ctx, m, findGopher{})
+	require.NoError(t, err)
+	assert.Equal(t, "second", resp)
+}
+
+func TestSendRequest_SelfHandlingRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	resp, err := mediator.SendRequest[Gopher](ctx, m, searchGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", resp.Name)
+
+	// a registered handler takes precedence over the Handle function of the request
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, _ searchGopher) (Gopher, error) {
+		return Gopher{Name: "replaced"}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	resp, err = mediator.SendRequest[Gopher](ctx, m, searchGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", resp.Name)
+}
+
+func TestSend_RegisteredHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls []string
+	m := mediator.New(mediator.WithBehaviorsFor(mediator.NewRequestBehavior(
+		func(ctx context.Context, l *slog.Logger, req searchGopher, next mediator.NextFunc[searchGopher, Gopher]) (Gopher, error) {
+			calls = append(calls, req.name)
+			return next(ctx, l, req)
+		},
+	)))
+
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req searchGopher) (Gopher, error) {
+		return Gopher{Name: "registered " + req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	resp, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "registered Gus", resp.Name, "a registered handler takes precedence over the Handle function of the request")
+
+	resp, err = mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gert"))
+	require.NoError(t, err)
+	assert.Equal(t, "registered Gert", resp.Name)
+	assert.Equal(t, []string{"Gus", "Gert"}, calls, "the request behaviors of the type should be used")
+}
+
+func TestSendRequest_NoHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	resp, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.Error(t, err)
+	assert.Empty(t, resp)
+
+	var noHandlerErr mediator.ErrNoHandler
+	require.ErrorAs(t, err, &noHandlerErr)
+	assert.Equal(t, "findGopher", noHandlerErr.Request)
+}
+
+func TestSendRequest_OtherResponseType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		return Gopher{Name: req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	resp, err := mediator.SendRequest[string](ctx, m, findGopher{name: "Gus"})
+	assert.Empty(t, resp)
+	var noHandlerErr mediator.ErrNoHandler
+	require.ErrorAs(t, err, &noHandlerErr, "a handler with another response type shouldn't be used")
+
+	gopher, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name)
+}
+
+func TestRegisterHandler_Nil(t *testing.T) {
+	t.Parallel()
+
+	m := mediator.New()
+	err := mediator.RegisterHandler[findGopher, Gopher](m, nil)
+	require.Error(t, err)
+}
+
+func shortCircuit(resp any, err error) *testBehavior {
+	return &testBehavior{
+		handleFunc: func(_ context.Context, _ *slog.Logger, _ mediator.Message, _ mediator.Handler) (any, error) {
+			return resp, err
+		},
+	}
+}
+
+func TestSend_ResponseTypeError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		resp   any
+		actual string
+	}{
+		{name: "nil", resp: nil, actual: "nil"},
+		{name: "wrong_type", resp: "not a gopher", actual: "string"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := mediator.New(mediator.WithRequestBehaviors(&testBehavior{}, shortCircuit(c.resp, nil)))
+
+			resp, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+			assert.Empty(t, resp)
+
+			var rtErr mediator.ResponseTypeError
+			require.ErrorAs(t, err, &rtErr)
+			assert.Equal(t, "searchGopher", rtErr.Request)
+			assert.Equal(t, "*mediator_test.testBehavior", rtErr.Behavior)
+			assert.Equal(t, "mediator_test.Gopher", rtErr.Expected)
+			assert.Equal(t, c.actual, rtErr.Actual)
+		})
+	}
+}
+
+func TestSend_ResponseTypeErrorCustomPipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestPipeline(rebuildPipeline{behaviors: []mediator.Behavior{shortCircuit(5, nil)}}))
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	var rtErr mediator.ResponseTypeError
+	require.ErrorAs(t, err, &rtErr)
+	assert.Empty(t, rtErr.Behavior, "the behavior is unknown for custom pipelines")
+	assert.Equal(t, "int", rtErr.Actual)
+}
+
+func TestSend_NilResponse(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("with_error", func(t *testing.T) {
+		t.Parallel()
+
+		myErr := errors.New("rejected")
+		m := mediator.New(mediator.WithRequestBehaviors(shortCircuit(nil, myErr)))
+
+		resp, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+		require.Equal(t, myErr, err)
+		assert.Equal(t, Gopher{}, resp)
+	})
+
+	t.Run("nillable_type", func(t *testing.T) {
+		t.Parallel()
+
+		m := mediator.New(mediator.WithRequestBehaviors(shortCircuit(nil, nil)))
+
+		resp, err := mediator.Send[*Gopher](ctx, m, pingRequest{})
+		require.NoError(t, err)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestSend_ResponseHelpers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("zero_response", func(t *testing.T) {
+		t.Parallel()
+
+		behav := &testBehavior{
+			handleFunc: func(_ context.Context, _ *slog.Logger, msg mediator.Message, _ mediator.Handler) (any, error) {
+				return mediator.ZeroResponse(msg), nil
+			},
+		}
+		m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+		resp, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+		require.NoError(t, err)
+		assert.Equal(t, Gopher{}, resp)
+		assert.Nil(t, mediator.ZeroResponse(mediator.NewNotificationMessage[string]("some-event")))
+	})
+
+	t.Run("respond", func(t *testing.T) {
+		t.Parallel()
+
+		var respondErr error
+		behav := &testBehavior{
+			handleFunc: func(_ context.Context, _ *slog.Logger, msg mediator.Message, _ mediator.Handler) (any, error) {
+				_, respondErr = mediator.Respond(msg, "not a gopher")
+				return mediator.Respond(msg, Gopher{Name: "cached"})
+			},
+		}
+		m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+		resp, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+		require.NoError(t, err)
+		assert.Equal(t, "cached", resp.Name)
+
+		var rtErr mediator.ResponseTypeError
+		require.ErrorAs(t, respondErr, &rtErr)
+		assert.Equal(t, "searchGopher", rtErr.Request)
+	})
+}
+
+func TestSendRequest_ResponseTypeError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithRequestBehaviors(shortCircuit(nil, nil)))
+
+	_, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	var rtErr mediator.ResponseTypeError
+	require.ErrorAs(t, err, &rtErr)
+	assert.Equal(t, "findGopher", rtErr.Request)
+}
@@ -0,0 +1,89 @@
+package mediator
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+)
+
+// StreamRequest is a [Request] that produces multiple results instead of a single response.
+// It can be sent through the [Mediator] using [SendStream].
+//
+// Stream requests are useful for queries that return a large amount of results,
+// because the results don't have to be materialized all at once.
+type StreamRequest[T any] interface {
+	// Handle starts the [StreamRequest] and returns an iterator over the results.
+	// The iterator should stop when the context is cancelled.
+	Handle(ctx context.Context, l *slog.Logger) iter.Seq2[T, error]
+}
+
+// SendStream sends a [StreamRequest] using a [Sender] and returns an iterator over the results.
+//
+// The request is passed through the request [Pipeline] when the iterator is ranged over.
+// Behaviors in the [Pipeline] wrap the whole lifetime of the stream,
+// so a logging or tracing behavior measures the time until the last result is consumed.
+//
+// The stream stops producing when the consumer breaks out of the loop or when the context is cancelled.
+// An error ends the stream, it is yielded together with the zero value of T.
+//
+// The [Sender] interface is implemented by [Mediator].
+func SendStream[T any](ctx context.Context, m Sender, req StreamRequest[T], options ...SendOption) iter.Seq2[T, error] {
+	opts := newSendOptions(options)
+	return func(yield func(T, error) bool) {
+		var started, done bool
+		// emit makes sure nothing is yielded after the consumer stopped or an error ended the stream.
+		// A behavior could call the next handler again after that happened.
+		emit := func(res T, err error) bool {
+			if done {
+				return false
+			}
+			done = !yield(res, err) || err != nil
+			return !done
+		}
+
+		// every iteration over the stream is a new message
+		msg := streamRequestMessage[T]{req: req, metadata: newMetadata(ctx, opts.metadata)}
+		msg.consume = func(ctx context.Context, l *slog.Logger) (any, error) {
+			started = true
+			for res, err := range req.Handle(ctx, l) {
+				if err == nil {
+					err = ctx.Err()
+				}
+				if err != nil {
+					var zero T
+					emit(zero, err)
+					return nil, err
+				}
+				if !emit(res, nil) {
+					return nil, nil
+				}
+			}
+			return nil, nil
+		}
+
+		// the iterator that a behavior returns is consumed before the context is cancelled
+		ctx, cancel := opts.context(ctx, m, msg)
+		defer cancel()
+
+		resp, err := opts.handler(m.getRequestPipeline()).Handle(ctx, opts.logger(m), msg)
+		if done {
+			return
+		}
+		if err != nil {
+			// the error originates from the pipeline instead of the stream
+			var zero T
+			emit(zero, err)
+			return
+		}
+		if !started {
+			// the pipeline didn't call the stream, it might have returned its own iterator instead
+			if seq, ok := resp.(iter.Seq2[T, error]); ok {
+				for res, err := range seq {
+					if !emit(res, err) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
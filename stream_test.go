@@ -0,0 +1,156 @@
+package mediator_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+type listGophers struct {
+	names    []string
+	err      error
+	produced *int
+}
+
+func (q listGophers) Handle(ctx context.Context, _ *slog.Logger) iter.Seq2[Gopher, error] {
+	return func(yield func(Gopher, error) bool) {
+		for _, name := range q.names {
+			if q.produced != nil {
+				*q.produced++
+			}
+			if !yield(Gopher{Name: name}, nil) {
+				return
+			}
+		}
+		if q.err != nil {
+			yield(Gopher{}, q.err)
+		}
+	}
+}
+
+func TestSendStream(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var consumed int
+	var consumedInBehavior int
+	behav := &testBehavior{
+		handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+			resp, err := next.Handle(ctx, l, msg)
+			// the behavior should wrap the whole lifetime of the stream
+			consumedInBehavior = consumed
+			return resp, err
+		},
+	}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	names := []string{"Gus", "Gina", "Gary"}
+	var result []string
+	for gopher, err := range mediator.SendStream(ctx, m, listGophers{names: names}) {
+		require.NoError(t, err)
+		result = append(result, gopher.Name)
+		consumed++
+	}
+
+	assert.Equal(t, names, result)
+	assert.Equal(t, 1, behav.counter, "middleware called unexpected amount of times")
+	assert.Equal(t, len(names), consumedInBehavior)
+}
+
+func TestSendStream_Break(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New()
+
+	var produced int
+	req := listGophers{names: []string{"Gus", "Gina", "Gary"}, produced: &produced}
+	for gopher, err := range mediator.SendStream(ctx, m, req) {
+		require.NoError(t, err)
+		assert.Equal(t, "Gus", gopher.Name)
+		break
+	}
+	assert.Equal(t, 1, produced, "the stream should stop producing when the consumer breaks")
+}
+
+func TestSendStream_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := mediator.New()
+
+	var results int
+	var errs []error
+	for _, err := range mediator.SendStream(ctx, m, listGophers{names: []string{"Gus", "Gina", "Gary"}}) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results++
+		cancel()
+	}
+	assert.Equal(t, 1, results)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], context.Canceled)
+}
+
+func TestSendStream_Error(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	streamErr := errors.New("connection lost")
+
+	var behaviorErr error
+	behav := &testBehavior{
+		handleFunc: func(ctx context.Context, l *slog.Logger, msg mediator.Message, next mediator.Handler) (any, error) {
+			resp, err := next.Handle(ctx, l, msg)
+			behaviorErr = err
+			return resp, err
+		},
+	}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	var results int
+	var errs []error
+	for _, err := range mediator.SendStream(ctx, m, listGophers{names: []string{"Gus"}, err: streamErr}) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results++
+	}
+	assert.Equal(t, 1, results)
+	assert.Equal(t, []error{streamErr}, errs)
+	assert.Equal(t, streamErr, behaviorErr, "the stream error should pass through the pipeline")
+}
+
+func TestSendStream_PipelineError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	rejectErr := errors.New("rejected")
+
+	behav := &testBehavior{
+		handleFunc: func(_ context.Context, _ *slog.Logger, _ mediator.Message, _ mediator.Handler) (any, error) {
+			return nil, rejectErr
+		},
+	}
+	m := mediator.New(mediator.WithRequestBehaviors(behav))
+
+	var produced int
+	var errs []error
+	for _, err := range mediator.SendStream(ctx, m, listGophers{names: []string{"Gus"}, produced: &produced}) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{rejectErr}, errs)
+	assert.Zero(t, produced)
+}
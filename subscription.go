@@ -0,0 +1,59 @@
+package mediator
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Subscription is returned by [Subscribe] and can be used to remove the subscribed handler.
+	Subscription interface {
+		// Unsubscribe removes the handler from the [Publisher].
+		// It is safe to call Unsubscribe more than once, and while a [Publish] for the same type is in flight.
+		// A [Publish] that already started might still call the handler.
+		Unsubscribe()
+		// Done returns a channel that is closed when the handler is unsubscribed.
+		Done() <-chan struct{}
+	}
+
+	subscription struct {
+		once   sync.Once
+		done   chan struct{}
+		remove func()
+		// stop stops the auto unsubscribe of [SubscribeContext].
+		stop func() bool
+		mu   sync.Mutex
+	}
+)
+
+func newSubscription(remove func()) *subscription {
+	return &subscription{
+		done:   make(chan struct{}),
+		remove: remove,
+	}
+}
+
+// bind unsubscribes when the context is done.
+func (s *subscription) bind(ctx context.Context) {
+	stop := context.AfterFunc(ctx, s.Unsubscribe)
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+}
+
+func (s *subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.remove()
+		close(s.done)
+
+		s.mu.Lock()
+		if s.stop != nil {
+			s.stop()
+		}
+		s.mu.Unlock()
+	})
+}
+
+func (s *subscription) Done() <-chan struct{} {
+	return s.done
+}
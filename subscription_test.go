@@ -0,0 +1,133 @@
+package mediator_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+func TestSubscription_Unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New()
+
+	var calls atomic.Int32
+	handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls.Add(1)
+		return nil
+	})
+	sub, err := mediator.Subscribe(p, handler)
+	require.NoError(t, err)
+	other, err := mediator.Subscribe(p, handler)
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(ctx, p, "some-event"))
+	assert.Equal(t, int32(2), calls.Load())
+
+	sub.Unsubscribe()
+	// unsubscribing twice is safe
+	sub.Unsubscribe()
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("the done channel should be closed after unsubscribing")
+	}
+	select {
+	case <-other.Done():
+		t.Fatal("unsubscribing shouldn't affect other subscriptions")
+	default:
+	}
+
+	require.NoError(t, mediator.Publish(ctx, p, "some-event"))
+	assert.Equal(t, int32(3), calls.Load(), "only the remaining subscriber should be called")
+}
+
+func TestSubscribeContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := mediator.New()
+
+	var calls atomic.Int32
+	handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		calls.Add(1)
+		return nil
+	})
+	sub, err := mediator.SubscribeContext(ctx, p, handler)
+	require.NoError(t, err)
+
+	require.NoError(t, mediator.Publish(context.Background(), p, "some-event"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	cancel()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the subscription should end when the context is done")
+	}
+
+	require.NoError(t, mediator.Publish(context.Background(), p, "some-event"))
+	assert.Equal(t, int32(1), calls.Load(), "the handler shouldn't be called after the context is done")
+}
+
+func TestSubscribeContext_Done(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := mediator.New()
+
+	handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		t.Error("the handler shouldn't be subscribed")
+		return nil
+	})
+	_, err := mediator.SubscribeContext(ctx, p, handler)
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.NoError(t, mediator.Publish(context.Background(), p, "some-event"))
+}
+
+func TestSubscription_UnsubscribeDuringPublish(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := mediator.New(mediator.WithParallelNotifications())
+
+	handler := mediator.NewNotificationHandler(func(_ context.Context, _ *slog.Logger, _ string) error {
+		return nil
+	})
+	// keep one subscriber around, so publish always has something to do
+	_, err := mediator.Subscribe(p, handler)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				assert.NoError(t, mediator.Publish(ctx, p, "some-event"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				sub, err := mediator.Subscribe(p, handler)
+				assert.NoError(t, err)
+				sub.Unsubscribe()
+			}
+		}()
+	}
+	wg.Wait()
+}
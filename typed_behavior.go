@@ -0,0 +1,152 @@
+package mediator
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+type (
+	// RequestBehavior is a [Behavior] for a single request type Req that responds with Resp.
+	// Unlike [Behavior], it works on the typed request and response,
+	// so it doesn't have to type-switch on the [Message] or assert the response.
+	//
+	// Request behaviors are registered on the [Mediator] with [WithBehaviorsFor].
+	// They are composed into the pipeline of their request type only, and run after the behaviors of the [Pipeline],
+	// right before the request is handled.
+	// A [Command] doesn't have a response, its behaviors use struct{} as Resp.
+	RequestBehavior[Req any, Resp any] interface {
+		Handle(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error)
+	}
+
+	// NextFunc calls the next [RequestBehavior] or the handler of the request.
+	NextFunc[Req any, Resp any] func(ctx context.Context, l *slog.Logger, req Req) (Resp, error)
+
+	requestBehavior[Req any, Resp any] struct {
+		handleFunc func(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error)
+	}
+
+	// typedKey identifies the request behaviors of a request and response type.
+	typedKey struct {
+		req  reflect.Type
+		resp reflect.Type
+	}
+
+	// typedBehaviors is the chain of request behaviors of a request type.
+	typedBehaviors[Req any, Resp any] []RequestBehavior[Req, Resp]
+
+	// requestChain handles a [Request] through the request behaviors of its type.
+	// It is used by messages that only know the response type of the request.
+	requestChain[Resp any] interface {
+		handleRequest(ctx context.Context, l *slog.Logger, req Request[Resp]) (Resp, error)
+	}
+
+	// handlerChain handles a [Request] with the [RequestHandler] that is registered for its type,
+	// through the request behaviors of the type.
+	handlerChain[Req any, Resp any] struct {
+		handler RequestHandler[Req, Resp]
+		typed   typedBehaviors[Req, Resp]
+	}
+
+	// commandChain handles a [Command] through the request behaviors of its type.
+	commandChain interface {
+		handleCommand(ctx context.Context, l *slog.Logger, cmd Command) error
+	}
+)
+
+func (rb requestBehavior[Req, Resp]) Handle(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error) {
+	return rb.handleFunc(ctx, l, req, next)
+}
+
+// NewRequestBehavior is a utility function for creating a [RequestBehavior] without having to define a type.
+func NewRequestBehavior[Req any, Resp any](handleFunc func(ctx context.Context, l *slog.Logger, req Req, next NextFunc[Req, Resp]) (Resp, error)) RequestBehavior[Req, Resp] {
+	return requestBehavior[Req, Resp]{
+		handleFunc: handleFunc,
+	}
+}
+
+// WithBehaviorsFor adds [RequestBehavior] instances for requests of type Req that respond with Resp.
+// The behaviors are used by [Send], [SendQuery] and [SendRequest], the first behavior is the outermost.
+// The behaviors of a [Command] use struct{} as Resp and are used by [Dispatch].
+// Calling the option multiple times for the same types adds to the existing behaviors.
+//
+// Req has to be the exact type of the request that is sent, it can't be an interface that the request implements.
+//
+//	m := mediator.New(mediator.WithBehaviorsFor[CreateOrder, Order](auditOrders))
+func WithBehaviorsFor[Req any, Resp any](behaviors ...RequestBehavior[Req, Resp]) Option {
+	return func(o *options) {
+		if o.typedBehaviors == nil {
+			o.typedBehaviors = make(map[typedKey]any)
+		}
+		k := typedKey{req: reflect.TypeFor[Req](), resp: reflect.TypeFor[Resp]()}
+		existing, _ := o.typedBehaviors[k].(typedBehaviors[Req, Resp])
+		o.typedBehaviors[k] = append(existing[:len(existing):len(existing)], behaviors...)
+	}
+}
+
+// handle calls the behaviors of the chain, and finally the terminal function.
+func (bs typedBehaviors[Req, Resp]) handle(ctx context.Context, l *slog.Logger, req Req, terminal NextFunc[Req, Resp]) (Resp, error) {
+	next := terminal
+	for i := range bs {
+		b, n := bs[len(bs)-1-i], next
+		next = func(ctx context.Context, l *slog.Logger, req Req) (Resp, error) {
+			return b.Handle(ctx, l, req, n)
+		}
+	}
+	return next(ctx, l, req)
+}
+
+func (bs typedBehaviors[Req, Resp]) handleRequest(ctx context.Context, l *slog.Logger, req Request[Resp]) (Resp, error) {
+	r, ok := req.(Req)
+	if !ok {
+		return req.Handle(ctx, l)
+	}
+	return bs.handle(ctx, l, r, func(ctx context.Context, l *slog.Logger, r Req) (Resp, error) {
+		// a behavior could have replaced the request, Req implements Request[Resp] because req does
+		return any(r).(Request[Resp]).Handle(ctx, l)
+	})
+}
+
+func (bs typedBehaviors[Req, Resp]) handleCommand(ctx context.Context, l *slog.Logger, cmd Command) error {
+	c, ok := cmd.(Req)
+	if !ok {
+		return cmd.Handle(ctx, l)
+	}
+	_, err := bs.handle(ctx, l, c, func(ctx context.Context, l *slog.Logger, c Req) (Resp, error) {
+		var zero Resp
+		// a behavior could have replaced the command, Req implements Command because cmd does
+		return zero, any(c).(Command).Handle(ctx, l)
+	})
+	return err
+}
+
+func (c handlerChain[Req, Resp]) handleRequest(ctx context.Context, l *slog.Logger, req Request[Resp]) (Resp, error) {
+	r, ok := req.(Req)
+	if !ok {
+		return req.Handle(ctx, l)
+	}
+	return c.typed.handle(ctx, l, r, c.handler.Handle)
+}
+
+// requestChainFor returns the registered handler or the request behaviors for the request, or nil if it has neither.
+func requestChainFor[Resp any](m Sender, req Request[Resp]) requestChain[Resp] {
+	k := typedKey{req: reflect.TypeOf(req), resp: reflect.TypeFor[Resp]()}
+	// a registered handler takes precedence over the Handle function of the request
+	if h, ok := m.getRequestHandler(k); ok {
+		return h.(requestChain[Resp])
+	}
+	chain, _ := m.getTypedBehaviors(k).(requestChain[Resp])
+	return chain
+}
+
+// typedBehaviorsFor returns the request behaviors for requests of type Req, or nil if it has none.
+func typedBehaviorsFor[Req any, Resp any](m Sender) typedBehaviors[Req, Resp] {
+	bs, _ := m.getTypedBehaviors(typedKey{req: reflect.TypeFor[Req](), resp: reflect.TypeFor[Resp]()}).(typedBehaviors[Req, Resp])
+	return bs
+}
+
+// commandChainFor returns the request behaviors for the command, or nil if it has none.
+func commandChainFor(m Sender, cmd Command) commandChain {
+	chain, _ := m.getTypedBehaviors(typedKey{req: reflect.TypeOf(cmd), resp: reflect.TypeFor[struct{}]()}).(commandChain)
+	return chain
+}
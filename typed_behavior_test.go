@@ -0,0 +1,142 @@
+package mediator_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+// shoutBehavior makes the name of the searched gopher uppercase.
+func shoutBehavior() mediator.RequestBehavior[searchGopher, Gopher] {
+	return mediator.NewRequestBehavior(func(ctx context.Context, l *slog.Logger, req searchGopher, next mediator.NextFunc[searchGopher, Gopher]) (Gopher, error) {
+		req.name = strings.ToUpper(req.name)
+		return next(ctx, l, req)
+	})
+}
+
+func TestRequestBehavior_Send(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := mediator.New(mediator.WithBehaviorsFor(shoutBehavior()))
+
+	gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "GUS", gopher.Name, "the behavior should be able to replace the request")
+
+	gopher, err = mediator.SendQuery(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "GUS", gopher.Name)
+}
+
+func TestRequestBehavior_OnlyForItsType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	called := false
+	behav := mediator.NewRequestBehavior(func(ctx context.Context, l *slog.Logger, req findGopher, next mediator.NextFunc[findGopher, Gopher]) (Gopher, error) {
+		called = true
+		return next(ctx, l, req)
+	})
+	m := mediator.New(mediator.WithBehaviorsFor(behav))
+
+	gopher, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, "Gus", gopher.Name)
+	assert.False(t, called, "the behavior shouldn't be used for other request types")
+}
+
+func TestRequestBehavior_SendRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	notFound := errors.New("gopher not found")
+	behav := mediator.NewRequestBehavior(func(ctx context.Context, l *slog.Logger, req findGopher, next mediator.NextFunc[findGopher, Gopher]) (Gopher, error) {
+		if req.name == "" {
+			return Gopher{}, notFound
+		}
+		gopher, err := next(ctx, l, req)
+		gopher.Color = "purple"
+		return gopher, err
+	})
+	m := mediator.New(mediator.WithBehaviorsFor(behav))
+
+	handlerCalls := 0
+	handler := mediator.NewRequestHandler(func(_ context.Context, _ *slog.Logger, req findGopher) (Gopher, error) {
+		handlerCalls++
+		return Gopher{Name: req.name}, nil
+	})
+	require.NoError(t, mediator.RegisterHandler(m, handler))
+
+	gopher, err := mediator.SendRequest[Gopher](ctx, m, findGopher{name: "Gus"})
+	require.NoError(t, err)
+	assert.Equal(t, Gopher{Name: "Gus", Color: "purple"}, gopher)
+
+	_, err = mediator.SendRequest[Gopher](ctx, m, findGopher{})
+	require.ErrorIs(t, err, notFound)
+	assert.Equal(t, 1, handlerCalls, "the behavior should be able to short-circuit the handler")
+}
+
+func TestRequestBehavior_Order(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls []string
+	record := func(name string) mediator.RequestBehavior[searchGopher, Gopher] {
+		return mediator.NewRequestBehavior(func(ctx context.Context, l *slog.Logger, req searchGopher, next mediator.NextFunc[searchGopher, Gopher]) (Gopher, error) {
+			calls = append(calls, name)
+			return next(ctx, l, req)
+		})
+	}
+	m := mediator.New(
+		mediator.WithRequestBehaviors(recordBehavior("pipeline", &calls)),
+		mediator.WithBehaviorsFor(record("typed-1"), record("typed-2")),
+		mediator.WithBehaviorsFor(record("typed-3")),
+	)
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pipeline:request", "typed-1", "typed-2", "typed-3"}, calls)
+}
+
+func TestRequestBehavior_ResponseTypeMustMatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	called := false
+	behav := mediator.NewRequestBehavior(func(_ context.Context, _ *slog.Logger, _ searchGopher, _ mediator.NextFunc[searchGopher, string]) (string, error) {
+		called = true
+		return "", nil
+	})
+	m := mediator.New(mediator.WithBehaviorsFor(behav))
+
+	_, err := mediator.Send(ctx, m, NewSearchGopherQuery("Gus"))
+	require.NoError(t, err)
+	assert.False(t, called, "searchGopher responds with a Gopher, not a string")
+}
+
+func TestRequestBehavior_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	behav := mediator.NewRequestBehavior(func(ctx context.Context, l *slog.Logger, cmd *renameGopher, next mediator.NextFunc[*renameGopher, struct{}]) (struct{}, error) {
+		cmd.name = strings.ToUpper(cmd.name)
+		return next(ctx, l, cmd)
+	})
+	m := mediator.New(mediator.WithBehaviorsFor(behav))
+
+	cmd := &renameGopher{name: "Gus"}
+	require.NoError(t, mediator.Dispatch(ctx, m, cmd))
+	assert.Equal(t, "GUS", cmd.gopher.Name, "the behavior of the command type should run")
+
+	cmdErr := errors.New("gopher is hibernating")
+	err := mediator.Dispatch(ctx, m, &renameGopher{err: cmdErr})
+	require.ErrorIs(t, err, cmdErr)
+}
@@ -0,0 +1,217 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// QueuePolicy decides what a [WorkerPool] does when its queue is full.
+type QueuePolicy int
+
+const (
+	// QueueBlock waits until there is room in the queue, or until the context is done.
+	QueueBlock QueuePolicy = iota
+	// QueueDrop drops the handler call. The [PublishStrategy] doesn't treat this as an error.
+	QueueDrop
+	// QueueReject returns [ErrQueueFull] for the handler call.
+	QueueReject
+)
+
+func (p QueuePolicy) String() string {
+	switch p {
+	case QueueBlock:
+		return "block"
+	case QueueDrop:
+		return "drop"
+	case QueueReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrQueueFull is returned when a [WorkerPool] with the [QueueReject] policy has a full queue.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrHandlerDropped is returned when a [WorkerPool] with the [QueueDrop] policy has a full queue.
+	ErrHandlerDropped = errors.New("worker pool queue is full, handler call dropped")
+	// ErrPoolClosed is returned when a task is submitted to a [WorkerPool] that is shut down.
+	ErrPoolClosed = errors.New("worker pool is shut down")
+)
+
+type (
+	// WorkerPool runs notification handlers on a fixed amount of goroutines.
+	// It is used by the parallel [PublishStrategy] implementations when it is added to the [Mediator]
+	// with [WithWorkerPool].
+	//
+	// Handler calls wait in a queue until a worker is available.
+	// What happens when the queue is full is decided by the [QueuePolicy].
+	//
+	// Be careful with publishing notifications from inside a handler that runs on a pool with the [QueueBlock] policy,
+	// all workers could end up waiting on a full queue.
+	WorkerPool struct {
+		tasks   chan poolTask
+		policy  QueuePolicy
+		metrics WorkerPoolMetrics
+		wg      sync.WaitGroup
+
+		// mu guards closed, submitters hold the read lock while they send on tasks.
+		mu       sync.RWMutex
+		closed   bool
+		quit     chan struct{}
+		quitOnce sync.Once
+	}
+
+	// WorkerPoolMetrics receives metrics from a [WorkerPool].
+	// The functions are called synchronously, so they should return quickly.
+	WorkerPoolMetrics interface {
+		// RecordQueueDepth is called with the amount of queued tasks every time a task is queued or started.
+		RecordQueueDepth(depth int)
+		// RecordQueueWait is called with the time a task waited in the queue before a worker started it.
+		RecordQueueWait(wait time.Duration)
+	}
+
+	// WorkerPoolOption defines the method to customize [NewWorkerPool].
+	WorkerPoolOption  func(*workerPoolOptions)
+	workerPoolOptions struct {
+		queueSize int
+		policy    QueuePolicy
+		metrics   WorkerPoolMetrics
+	}
+
+	poolTask struct {
+		f      func()
+		queued time.Time
+	}
+
+	noopPoolMetrics struct{}
+)
+
+func (noopPoolMetrics) RecordQueueDepth(int)          {}
+func (noopPoolMetrics) RecordQueueWait(time.Duration) {}
+
+// WithQueueSize sets the amount of handler calls that can wait for a worker.
+// The default is the amount of workers.
+func WithQueueSize(size int) WorkerPoolOption {
+	return func(o *workerPoolOptions) {
+		o.queueSize = size
+	}
+}
+
+// WithQueuePolicy sets the [QueuePolicy] that is used when the queue is full.
+// The default is [QueueBlock].
+func WithQueuePolicy(policy QueuePolicy) WorkerPoolOption {
+	return func(o *workerPoolOptions) {
+		o.policy = policy
+	}
+}
+
+// WithPoolMetrics adds a [WorkerPoolMetrics] implementation that receives the metrics of the pool.
+func WithPoolMetrics(metrics WorkerPoolMetrics) WorkerPoolOption {
+	return func(o *workerPoolOptions) {
+		o.metrics = metrics
+	}
+}
+
+// NewWorkerPool creates a [WorkerPool] and starts the given amount of workers.
+// The pool should be stopped with [WorkerPool.Shutdown] when it is no longer used.
+func NewWorkerPool(workers int, opt ...WorkerPoolOption) *WorkerPool {
+	workers = max(workers, 1)
+	// default options
+	opts := &workerPoolOptions{
+		queueSize: workers,
+		policy:    QueueBlock,
+		metrics:   noopPoolMetrics{},
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+
+	p := &WorkerPool{
+		tasks:   make(chan poolTask, max(opts.queueSize, 0)),
+		policy:  opts.policy,
+		metrics: opts.metrics,
+		quit:    make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for t := range p.tasks {
+		p.metrics.RecordQueueDepth(len(p.tasks))
+		p.metrics.RecordQueueWait(time.Since(t.queued))
+		t.f()
+	}
+}
+
+// Submit queues f to be run by a worker.
+// When the queue is full, the [QueuePolicy] decides if it waits, or returns [ErrHandlerDropped] or [ErrQueueFull].
+func (p *WorkerPool) Submit(ctx context.Context, f func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	t := poolTask{f: f, queued: time.Now()}
+	if p.policy == QueueBlock {
+		select {
+		case p.tasks <- t:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.quit:
+			return ErrPoolClosed
+		}
+	} else {
+		select {
+		case p.tasks <- t:
+		default:
+			if p.policy == QueueDrop {
+				return ErrHandlerDropped
+			}
+			return ErrQueueFull
+		}
+	}
+	p.metrics.RecordQueueDepth(len(p.tasks))
+	return nil
+}
+
+// QueueDepth returns the amount of tasks that are waiting for a worker.
+func (p *WorkerPool) QueueDepth() int {
+	return len(p.tasks)
+}
+
+// Shutdown stops accepting new tasks and waits until the queued and running tasks are finished,
+// or until the context is done.
+// Calling Shutdown more than once is safe.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.quitOnce.Do(func() {
+		// wake up submitters that wait for room in the queue
+		close(p.quit)
+
+		p.mu.Lock()
+		p.closed = true
+		close(p.tasks)
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
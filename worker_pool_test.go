@@ -0,0 +1,222 @@
+package mediator_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luukvdm/mediator"
+)
+
+type testPoolMetrics struct {
+	mu       sync.Mutex
+	depths   []int
+	waits    int
+	maxDepth int
+}
+
+func (m *testPoolMetrics) RecordQueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depths = append(m.depths, depth)
+	m.maxDepth = max(m.maxDepth, depth)
+}
+
+func (m *testPoolMetrics) RecordQueueWait(_ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits++
+}
+
+// blockWorkers occupies every worker of the pool until the returned function is called.
+func blockWorkers(t *testing.T, pool *mediator.WorkerPool, workers int) func() {
+	t.Helper()
+
+	var started sync.WaitGroup
+	started.Add(workers)
+	release := make(chan struct{})
+	for range workers {
+		require.NoError(t, pool.Submit(context.Background(), func() {
+			started.Done()
+			<-release
+		}))
+	}
+	started.Wait()
+	return func() { close(release) }
+}
+
+func TestWorkerPool_LimitsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	workers := 2
+	pool := mediator.NewWorkerPool(workers, mediator.WithQueueSize(10))
+	defer func() { require.NoError(t, pool.Shutdown(ctx)) }()
+	p := mediator.New(mediator.WithParallelNotifications(), mediator.WithWorkerPool(pool))
+
+	handlerCount := 10
+	var running, maxRunning, calls atomic.Int32
+	for range handlerCount {
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			calls.Add(1)
+			return nil
+		})
+	}
+
+	err := mediator.Publish(ctx, p, "some-event")
+	require.NoError(t, err)
+	assert.Equal(t, int32(handlerCount), calls.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int32(workers))
+}
+
+func TestWorkerPool_QueuePolicy(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		policy mediator.QueuePolicy
+		err    error
+	}{
+		{policy: mediator.QueueDrop, err: mediator.ErrHandlerDropped},
+		{policy: mediator.QueueReject, err: mediator.ErrQueueFull},
+	}
+	for _, c := range cases {
+		t.Run(c.policy.String(), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(1), mediator.WithQueuePolicy(c.policy))
+			defer func() { require.NoError(t, pool.Shutdown(ctx)) }()
+			release := blockWorkers(t, pool, 1)
+			defer release()
+
+			// fill the queue
+			require.NoError(t, pool.Submit(ctx, func() {}))
+
+			err := pool.Submit(ctx, func() {})
+			require.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestWorkerPool_QueuePolicyPublish(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("drop", func(t *testing.T) {
+		t.Parallel()
+
+		pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(1), mediator.WithQueuePolicy(mediator.QueueDrop))
+		defer func() { require.NoError(t, pool.Shutdown(ctx)) }()
+		release := blockWorkers(t, pool, 1)
+		defer release()
+		require.NoError(t, pool.Submit(ctx, func() {}), "fill the queue")
+
+		p := mediator.New(mediator.WithParallelNotifications(), mediator.WithWorkerPool(pool))
+		var called atomic.Bool
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			called.Store(true)
+			return nil
+		})
+
+		require.NoError(t, mediator.Publish(ctx, p, "some-event"), "dropped handler calls aren't errors")
+		assert.False(t, called.Load())
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		t.Parallel()
+
+		pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(1), mediator.WithQueuePolicy(mediator.QueueReject))
+		defer func() { require.NoError(t, pool.Shutdown(ctx)) }()
+		release := blockWorkers(t, pool, 1)
+		defer release()
+		require.NoError(t, pool.Submit(ctx, func() {}), "fill the queue")
+
+		p := mediator.New(mediator.WithParallelNotifications(), mediator.WithWorkerPool(pool))
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			return nil
+		})
+
+		require.ErrorIs(t, mediator.Publish(ctx, p, "some-event"), mediator.ErrQueueFull)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		t.Parallel()
+
+		pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(1))
+		defer func() { require.NoError(t, pool.Shutdown(ctx)) }()
+		release := blockWorkers(t, pool, 1)
+		defer release()
+		require.NoError(t, pool.Submit(ctx, func() {}), "fill the queue")
+
+		p := mediator.New(mediator.WithParallelNotifications(), mediator.WithWorkerPool(pool))
+		subscribeFunc(t, p, func(_ context.Context, _ *slog.Logger, _ string) error {
+			return nil
+		})
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, mediator.Publish(timeoutCtx, p, "some-event"), context.DeadlineExceeded)
+	})
+}
+
+func TestWorkerPool_Metrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metrics := &testPoolMetrics{}
+	pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(5), mediator.WithPoolMetrics(metrics))
+	release := blockWorkers(t, pool, 1)
+
+	for range 3 {
+		require.NoError(t, pool.Submit(ctx, func() {}))
+	}
+	assert.Equal(t, 3, pool.QueueDepth())
+
+	release()
+	require.NoError(t, pool.Shutdown(ctx))
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, 3, metrics.maxDepth)
+	assert.Equal(t, 0, metrics.depths[len(metrics.depths)-1])
+	assert.Equal(t, 4, metrics.waits, "every started task should report its queue time")
+}
+
+func TestWorkerPool_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool := mediator.NewWorkerPool(1, mediator.WithQueueSize(5))
+	release := blockWorkers(t, pool, 1)
+
+	var finished atomic.Int32
+	for range 3 {
+		require.NoError(t, pool.Submit(ctx, func() { finished.Add(1) }))
+	}
+
+	// the worker is still busy, so shutting down times out
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pool.Shutdown(timeoutCtx), context.DeadlineExceeded)
+	require.ErrorIs(t, pool.Submit(ctx, func() {}), mediator.ErrPoolClosed)
+
+	release()
+	require.NoError(t, pool.Shutdown(ctx))
+	assert.Equal(t, int32(3), finished.Load(), "queued tasks should finish before shutdown returns")
+}